@@ -13,10 +13,13 @@ type AntState int8
 const (
 	STATE_EXPLORE = iota
 	STATE_HUNT_FOOD
+	STATE_AMBUSH
 )
 
 type Ant struct {
+	id						int
 	loc						Location
+	prev					Location //where the ant was before this turn's order
 	target				Location
 	closestFood 	Location
 	exploreTarget Location
@@ -28,193 +31,330 @@ type Ant struct {
 	moveTarget		Location
 }
 
-func (ant *Ant) printMoves() {
+func (ant *Ant) printMoves(l *Logger) {
+	if !l.Enabled(LOG_DEBUG) {
+		return
+	}
 	debugDir := ""
 	for e := ant.moves.Front(); e != nil; e = e.Next() {
 		debugDir += fmt.Sprintf("%s,", e.Value.(Direction))
 	}	
-	log.Println(debugDir)	
+	l.Debugf("moves %s", debugDir)
 }
 
-const MAX_SIZE = 200
-const AREAS = 12
 
 type GarboAnt struct {
 	state 				*State
+	config				*Config
 	
-	exploreHeat1	[MAX_SIZE*MAX_SIZE]float32
-	exploreHeat2	[MAX_SIZE*MAX_SIZE]float32
-	exploreHeat   *[MAX_SIZE*MAX_SIZE]float32
-	exploreNext		*[MAX_SIZE*MAX_SIZE]float32
 	ants					map[Location]*Ant
+	tracker				*AntTracker
+	casualties		*Casualties
+	paths					*Pathfinder
+	regions				*Regions
+	terrain				*Terrain
+	economy				*FoodEconomy
 	foodHunted		map[Location]*Ant
-	knownHills		map[Location]bool
+	knownHills		map[Location]Item
 	knownWater		map[Location]bool
+	symmetry			*Symmetry
+	predictedHills	map[Location]float32
+	territory			*Territory
+	frontier			*FrontierMap
+	strategy			*Strategy
+	phase					*PhaseController
+	movesMade			[]*Ant
+	waterEscape		[]Direction
+	profiler			*Profiler
 	rand					rand.Rand
 	
-	antCountArea	[AREAS*AREAS]int
-	areaOffset		int
+	antCountArea	[]int
 }
 
-func NewBot(s *State) Bot {
+func NewBot(s *State, c *Config) Bot {
 	me := &GarboAnt{
-		ants: make(map[Location]*Ant),
-		knownHills: make(map[Location]bool),
+		tracker: NewAntTracker(s.Map),
+		casualties: NewCasualties(s.Map, s.AttackRadius2, c.DangerDecay, c.DangerThreshold),
+		paths: NewPathfinder(s.Map),
+		regions: NewRegions(s.Map),
+		terrain: NewTerrain(s.Map, c.CorridorWidth),
+		economy: NewFoodEconomy(s.Map, s.AttackRadius2, c.FoodMaxDistance, c.ContestRadius2, c.FoodDiscount, c.FoodContestedFactor),
+		knownHills: make(map[Location]Item),
 		knownWater: make(map[Location]bool),
 		foodHunted: make(map[Location]*Ant),
+		symmetry: NewSymmetry(s.Map, c.SymmetryBudget, c.SymmetryHillSupport),
+		territory: NewTerritory(s.Map),
+		frontier: NewFrontierMap(s.Map, c.StaleTurns, c.MaxFrontierClusters),
+		antCountArea: make([]int, c.Areas*c.Areas),
 		state: s,
+		config: c,
+	}
+	me.strategy = NewStrategy()
+	me.strategy.Register(&SkirmishBehavior{}, c.SkirmishPriority)
+	me.strategy.Register(&SquadBehavior{}, c.SquadPriority)
+	me.strategy.Register(&AttackBehavior{}, c.AttackPriority)
+	me.strategy.Register(&DefendBehavior{}, c.DefendPriority)
+	me.strategy.Register(&RaidBehavior{}, c.RaidPriority)
+	me.strategy.Register(&FoodBehavior{}, c.FoodPriority)
+	me.strategy.Register(&ExploreBehavior{}, c.ExplorePriority)
+	me.phase = NewPhaseController(c)
+	var err os.Error
+	if me.waterEscape, err = c.WaterEscapeMoves(); err != nil {
+		log.Panicf("Bad config (%s)", err)
 	}
-	me.exploreHeat = &me.exploreHeat1;
-	me.exploreNext = &me.exploreHeat2;
+	me.ants = me.tracker.Ants
 	return me
 }
 
+//SetProfiler has the bot time its turns with p, nil turns it off again.
+func (me *GarboAnt) SetProfiler(p *Profiler) {
+	me.profiler = p
+}
+
 func (me *GarboAnt) locToArea(loc Location) int {
 	row, col := me.state.Map.FromLocation(loc)
-	areaRow := int(float64(row) / float64(me.state.Map.Rows) * AREAS)
-	areaCol := int(float64(col) / float64(me.state.Map.Cols) * AREAS)
-	return areaRow * AREAS + areaCol
+	areas := me.config.Areas
+	areaRow := int(float64(row) / float64(me.state.Map.Rows) * float64(areas))
+	areaCol := int(float64(col) / float64(me.state.Map.Cols) * float64(areas))
+	return areaRow * areas + areaCol
 }
 
 func (me *GarboAnt) areaToLoc(loc int) Location {
-	areaRow := loc / AREAS
-	areaCol := loc % AREAS
-	row := int(float64(areaRow) / float64(AREAS) * float64(me.state.Map.Rows))
-	col := int(float64(areaCol) / float64(AREAS) * float64(me.state.Map.Cols))
+	areas := me.config.Areas
+	areaRow := loc / areas
+	areaCol := loc % areas
+	row := int(float64(areaRow) / float64(areas) * float64(me.state.Map.Rows))
+	col := int(float64(areaCol) / float64(areas) * float64(me.state.Map.Cols))
 	return me.state.Map.FromRowCol(row, col)
 }
 
-func (me *GarboAnt) SearchMap(s *State, source Location, final Location) (*list.List, bool) {
-	if (source == final) {
-		return nil, false
+//safeMove issues an order for the ant at loc if the destination is free,
+//and tracks the move so the ant state can be updated at the end of the turn.
+func (me *GarboAnt) safeMove(loc Location, dir Direction) bool {
+	s := me.state
+	target := s.Map.Move(loc, dir)
+	if s.Map.SafeDestination(target) && s.IssueOrderLoc(loc, dir) == nil {
+		me.ants[loc].target = target
+		me.movesMade = append(me.movesMade, me.ants[loc])
+		return true
 	}
+	return false
+}
 
-	type Node struct {
-		current Location
-		wave int
+//jointOrder works out an order to move ants from the squares in from to
+//the squares in to one at a time, each ant going once the square it moves
+//onto has been vacated. Moves that can't be made, when ants would have to go
+//round in a circle or a square stays taken by an ant that isn't moving, are
+//left out of the order and it's false.
+func jointOrder(m *Map, from, to []Location) ([]int, bool) {
+	order := []int{}
+	taken := make(map[Location]bool) //squares that changed hands so far
+	isTaken := func(loc Location) bool {
+		if t, changed := taken[loc]; changed {
+			return t
+		}
+		return m.Destinations.Has(loc)
 	}
-
-	// BFS
-	visited := make(map[Location]int)
-	queue := new(list.List)
-
-	dirs := []Direction{West, South, North, East}
-	invDir := []Direction{East, North, South, West}
-	nextStep := func(current Location, oldNode *Node) {
-		for _, dir := range dirs {
-			loc := s.Map.Move(current, dir)
-			_, alreadyVisited := visited[loc]
-			_, waterExists := me.knownWater[loc]
-			_, antExists := false, false //me.ants[loc]
-			if !alreadyVisited && !waterExists && !antExists {
-				wave := 1
-				if oldNode != nil {
-					wave = oldNode.wave + 1
+	done := make([]bool, len(from))
+	for left := len(from); left > 0; {
+		progress := false
+		for k := range from {
+			if done[k] {
+				continue
+			}
+			if from[k] != to[k] {
+				if m.Water.Has(to[k]) || isTaken(to[k]) {
+					continue
 				}
-				queue.PushBack(&Node{current: loc, wave: wave, })
+				order = append(order, k)
+				taken[from[k]] = false
+				taken[to[k]] = true
 			}
-		}		
+			done[k] = true
+			left--
+			progress = true
+		}
+		if !progress {
+			return order, false
+		}
 	}
+	return order, true
+}
 
-	buildPath := func(end Location) *list.List {
-		path := new(list.List)
-		for end != source {
-			bestWave := 9999999;
-			bestDir := North
-			bestLoc := Location(0)
-			for _, i := range dirs {
-				loc := s.Map.Move(end, dirs[i])
-				_, exists := visited[loc]
-				if exists && visited[loc] < bestWave {
-					bestWave = visited[loc]
-					bestDir = invDir[i]
-					bestLoc = loc
-				}
-			}
-			path.PushFront(bestDir)
-			end = bestLoc
+//moveTogether moves the ants at from to the squares in to as a whole, the
+//ants moving out of the way first. If the moves can't all be made nothing
+//is ordered and it returns false.
+func (me *GarboAnt) moveTogether(from, to []Location) bool {
+	m := me.state.Map
+	order, ok := jointOrder(m, from, to)
+	if !ok {
+		return false
+	}
+	for _, k := range order {
+		if !me.safeMove(from[k], direction(m, from[k], to[k])) {
+			me.state.Log.Ant(m, from[k]).Warnf("Joint move out of order")
 		}
-		return path
 	}
+	return true
+}
 
-	visited[source] = 0
-	nextStep(source, nil)
+func (me *GarboAnt) rebuildPath(ant *Ant, target Location) bool {
+	// No point searching the whole region for a target outside it
+	if !me.regions.Reachable(ant.loc, target) {
+		row, col := me.state.Map.FromLocation(target)
+		me.state.Log.Ant(me.state.Map, ant.loc).Debugf("Can't get to %d %d", row, col)
+		return false
+	}
 
-	for queue.Len() != 0 {
-		curEl := queue.Front()
-		queue.Remove(curEl)
-		current := curEl.Value.(*Node)
-		if current.current == final {
-			// Walk back from here, constructing the path
-			return buildPath(current.current), true
+	// Rebuild the path, around any fighting
+	me.profiler.Count("path_rebuilds", 1)
+	expansions := me.paths.Expansions
+	moves, valid := me.paths.Search(ant.loc, target)
+	me.profiler.Count("astar_expansions", me.paths.Expansions - expansions)
+	if valid {
+		ant.moves = moves
+		ant.moveTarget = target
+	} else {
+		if ant.loc != target {
+			me.regions.Blacklist(ant.loc, target)
 		}
-		if _, exists := visited[current.current]; exists {
-			continue;
+		return false
+	}
+	return true
+}
+
+//nextBFSMove moves ant one step along its path to target, building the path
+//first if there isn't one.
+func (me *GarboAnt) nextBFSMove(ant *Ant, target Location, retargetNow bool) bool {
+	if ant.moves == nil || (ant.moveTarget != target && retargetNow) {
+		if (!me.rebuildPath(ant, target)) {
+			return false
 		}
-		visited[current.current] = current.wave
-		nextStep(current.current, current)
 	}
 
-	return nil, false
+	// Move along the path, if we get stuck, re-path
+	front := ant.moves.Front()
+	dir := front.Value.(Direction)
+	// Check explicitly for water - means we need to rebuild path
+	if me.knownWater[me.state.Map.Move(ant.loc, dir)] {
+		if (!me.rebuildPath(ant, target)) {
+			return false
+		}
+		front = ant.moves.Front()
+		dir = front.Value.(Direction)
+	}
+
+	success := me.safeMove(ant.loc, dir)
+	if (success) {
+		ant.moves.Remove(front)
+		if (ant.moves.Len() == 0) {
+			ant.moves = nil
+		}
+		return true
+	}
+	
+	// Otherwise, if we fail to move, it's because another ant is in the way
+	return false
+}
+
+//fightStep is the first step of the shortest path from ant to target, cost
+//layers left out, or NoMovement if there's no way there. It's for the ants
+//we send into a fight, which the layers would only steer away from it.
+func (me *GarboAnt) fightStep(ant *Ant, target Location) Direction {
+	if ant.loc == target || !me.regions.Reachable(ant.loc, target) {
+		return NoMovement
+	}
+	expansions := me.paths.Expansions
+	moves, valid := me.paths.SearchFree(ant.loc, target)
+	me.profiler.Count("astar_expansions", me.paths.Expansions - expansions)
+	if !valid {
+		return NoMovement
+	}
+	return moves.Front().Value.(Direction)
+}
+
+func (me *GarboAnt) tryAnyMove(ant *Ant) {
+	for dir := Direction(0); dir < 4; dir++ {
+		if (me.safeMove(ant.loc, dir)) {
+			return
+		}
+	}
+}
+
+//updateHills remembers the enemy hills in sight, and forgets a hill only once
+//its square is in sight again with no hill on it.
+func (me *GarboAnt) updateHills() {
+	s := me.state
+	for _, loc := range s.Map.Hills.Locations() {
+		if hill := s.Map.Hills.At(loc); hill.IsEnemyHill() {
+			me.knownHills[loc] = hill
+		}
+	}
+	for loc := range me.knownHills {
+		if s.Map.Item(loc) != UNKNOWN && !s.Map.Hills.At(loc).IsEnemyHill() {
+			row, col := s.Map.FromLocation(loc)
+			s.Log.Infof("Hill killed at %d %d", row, col)
+			me.knownHills[loc] = 0, false
+		}
+	}
+}
+
+//predictHills keeps the hills the symmetry predicts with enough confidence,
+//less the ones whose squares are in sight with no hill on them.
+func (me *GarboAnt) predictHills() {
+	s := me.state
+	me.predictedHills = me.symmetry.PredictHills()
+	for loc, confidence := range me.predictedHills {
+		empty := s.Map.Item(loc) != UNKNOWN && !s.Map.Hills.At(loc).IsEnemyHill()
+		if confidence < float32(me.config.MinHillConfidence) || empty {
+			me.predictedHills[loc] = 0, false
+		}
+	}
 }
 
 //DoTurn is where you should do your bot's actual work.
 func (me *GarboAnt) DoTurn(s *State) os.Error {
 	startTime := time.Nanoseconds();
+	me.profiler.Begin("sync")
 	
-	// Mark all ants as not seen so far
-	for _, ant := range me.ants {
-		ant.seenThisTurn = false
+	// Work out which ants are still alive and where they ended up
+	r := me.tracker.Reconcile()
+	me.ants = me.tracker.Ants
+	for _, ant := range r.Born {
+		me.antCountArea[me.locToArea(ant.loc)]++;
 	}
-	
-	// Check to see which ants are alive and in the place we thought they should be
-	for loc, ant := range s.Map.Ants {
-		if ant != MY_ANT {
-			continue
-		}
-	 	_, exists := me.ants[loc]
-		if !exists {
-			me.ants[loc] = &Ant{ state: STATE_EXPLORE,}
-			me.ants[loc].loc = loc
-			me.antCountArea[me.locToArea(loc)]++;
-		} else {
-			if me.ants[loc].loc != loc {
-				log.Println("Ant state corrupted")
-			}			
-		}
-		me.ants[loc].seenThisTurn = true		
+	for _, ant := range r.Stuck {
+		me.antCountArea[me.locToArea(ant.target)]--;
+		me.antCountArea[me.locToArea(ant.loc)]++;
+		s.Log.Ant(s.Map, ant.loc).Debugf("Ant %d didn't move", ant.id)
 	}
-	
-	for loc, ant := range me.ants {
-		// Remove killed ants from state
-		if !ant.seenThisTurn {
-			me.ants[loc] = nil, false
-			me.antCountArea[me.locToArea(loc)]--;
-			log.Println("Ant killed at ", loc)
-		}
+	for _, ant := range r.Died {
+		me.antCountArea[me.locToArea(ant.loc)]--;
+	}
+	for _, c := range me.casualties.Update(s.Turn, r.Died, me.knownHills) {
+		s.Log.Ant(s.Map, c.Loc).Infof("Ant killed (%s)", c.Cause)
 	}
 
 	// Anything that can't be seen is highest priority
+	me.profiler.Begin("scan")
 	for row := 0; row < s.Map.Rows; row++ {
 		for col := 0; col < s.Map.Cols; col++ {
 			loc := s.Map.FromRowCol(row, col)
 			item := s.Map.Item(loc)
 
-			// Track hills
-			if item.IsEnemyHill() {
-				me.knownHills[loc] = true
-			} else {
-				_, isHill := me.knownHills[loc]
-				if isHill {
-					// Handle hills being killed
-					log.Println("Hill killed!")
-					me.knownHills[loc] = false, false
-				}
+			// Track what's been explored, for the dead ends
+			if item != UNKNOWN {
+				me.terrain.See(loc)
+			}
+			if item.IsHill() {
+				me.terrain.AddHill(loc)
 			}
-			
+
 			// Track water
-			if item == WATER {
+			if item == WATER && !me.knownWater[loc] {
 				me.knownWater[loc] = true
+				me.regions.AddWater(loc)
+				me.terrain.AddWater(loc)
 			}
 			if item != FOOD {
 				me.foodHunted[loc] = nil, false
@@ -226,190 +366,82 @@ func (me *GarboAnt) DoTurn(s *State) os.Error {
 			}
 		}
 	}
-/*
-	str := ""
-	for row := 0; row < AREAS; row++ {
-	    for col := 0; col < AREAS; col++ {
-	        str += fmt.Sprintf( "%d,", me.antCountArea[row*AREAS+col] )
-	    }
-	    str += "\n"
-	}
-	log.Println(str)
-*/
-	// Track all the safe moves made
-	movesMade := []*Ant{}
-	safeMove := func(loc Location, dir Direction) bool {
-		target := s.Map.Move(loc, dir)
-		if s.Map.SafeDestination(target) {
-			me.ants[loc].target = target
-			movesMade = append(movesMade, me.ants[loc])
-
-			s.IssueOrderLoc(loc, dir)
-			return true
-		}
-		return false
-	}
 
-	rebuildPath := func(ant *Ant, target Location) bool {
-		// Rebuild the path
-		moves, valid := me.SearchMap(s, ant.loc, target)
-		if valid {
-			ant.moves = moves
-			ant.moveTarget = target
-		} else {
-			return false
-		}
-		return true
-	}
+	me.updateHills()
 
-	nextBFSMove := func(ant *Ant, target Location, retargetNow bool) bool {
-		if ant.moves == nil || (ant.moveTarget != target && retargetNow) {
-			if (!rebuildPath(ant, target)) {
-				return false
-			}
-		}
+	// Split the land up where the new water cuts it
+	me.profiler.Begin("regions")
+	floods := me.regions.Floods
+	me.regions.Update()
+	me.profiler.Count("region_floods", me.regions.Floods - floods)
 
-		// Move along the path, if we get stuck, re-path
-		front := ant.moves.Front()
-		dir := front.Value.(Direction)
-		// Check explicitly for water - means we need to rebuild path
-		if me.knownWater[s.Map.Move(ant.loc, dir)] {
-			if (!rebuildPath(ant, target)) {
-				return false
-			}
-			front = ant.moves.Front()
-			dir = front.Value.(Direction)
-		}
+	// Find the chokepoints, corridors and dead ends the new water makes
+	me.profiler.Begin("terrain")
+	rebuilds := me.terrain.Rebuilds
+	me.terrain.Update()
+	me.profiler.Count("terrain_rebuilds", me.terrain.Rebuilds - rebuilds)
 
-		success := safeMove(ant.loc, dir)
-		if (success) {
-			ant.moves.Remove(front)
-			if (ant.moves.Len() == 0) {
-				ant.moves = nil
-			}
-			return true
-		}
-		
-		// Otherwise, if we fail to move, it's because another ant is in the way
-		return false
-	}
+	// Guess where the hills we haven't seen are from the map's symmetry
+	me.profiler.Begin("symmetry")
+	me.symmetry.Update(s.Map)
+	me.predictHills()
 
-	for _, ant := range me.ants {
-		// If we are hunting food, but it has disappeared, switch back to exploring
-		if ant.state == STATE_HUNT_FOOD && s.Map.Item(ant.closestFood) != FOOD {
-			ant.state = STATE_EXPLORE
-			me.foodHunted[ant.loc] = nil, false
-		}
-		// Exploring ants will hunt for food if they find any
-		if ant.state == STATE_EXPLORE {
-			bestLen := 9999999
-			s.Map.DoInRad(ant.loc, s.ViewRadius2, func(row, col int) {
-				loc := s.Map.FromRowCol(row, col)
-				if s.Map.Food[loc] {
-					moves, valid := me.SearchMap(s, ant.loc, loc)
-					if valid && moves.Len() < bestLen {
-						bestLen = moves.Len()
-						ant.moves = moves
-						ant.moveTarget = loc
-						ant.closestFood = loc
-						ant.state = STATE_HUNT_FOOD
-					}
-				}
-			})
-		}
+	// Split the map up by who gets to each square first
+	me.profiler.Begin("territory")
+	sources := make(map[Location]int)
+	for loc, hill := range me.knownHills {
+		sources[loc] = hill.Player()
 	}
-	
-	findNewTarget := func(ant *Ant) {
-		wrap := AREAS*AREAS
-		me.areaOffset = (me.areaOffset + 3) % 8
-		
-		// Go to the section with the fewest ants
-/*		bestArea := 0
-		bestCount := 99999
-		areaDirs := [8]int{-1,AREAS,1,-AREAS,AREAS-1,AREAS+1,-AREAS-1,-AREAS+1}
-		me.areaOffset = (me.areaOffset + 3) % 8
-		for i := me.areaOffset; i < me.areaOffset + 8; i++ {
-			actual := me.locToArea(ant.loc) + areaDirs[i % 8]
-			if actual < 0 {
-				actual += wrap
-			} else if actual >= wrap {
-				actual -= wrap
-			}
-			if me.antCountArea[actual] < bestCount {
-				bestCount = me.antCountArea[actual]
-				bestArea = actual
-			}
-		}*/
-		
-/*		me.areaOffset = (me.areaOffset + 47) % wrap
-		for i := 0; i < wrap; i++ {
-			actual := (i + me.areaOffset) % wrap
-			if me.antCountArea[actual] < bestCount {
-				bestCount = me.antCountArea[actual]
-				bestArea = actual
-			}
-		}*/
-		
-		bestArea := rand.Intn(wrap)
-		
-		// Now, path there
-		ant.exploreTarget = me.areaToLoc(bestArea)
-		row, col := s.Map.FromLocation(ant.exploreTarget)
-		w := s.Map.Cols / AREAS
-		h := s.Map.Rows / AREAS
-		row = row + rand.Intn(w) - (w / 2)
-		col = col + rand.Intn(h) - (h / 2)
-		ant.exploreTarget = s.Map.FromRowCol(row, col)
-		for me.knownWater[ant.exploreTarget] {
-			ant.exploreTarget = s.Map.Move(ant.exploreTarget, North)
-			ant.exploreTarget = s.Map.Move(ant.exploreTarget, North)
-			ant.exploreTarget = s.Map.Move(ant.exploreTarget, East)
-		}
+	for _, loc := range s.Map.Hills.Locations() {
+		sources[loc] = s.Map.Hills.At(loc).Player()
+	}
+	for _, loc := range s.Map.Ants.Locations() {
+		sources[loc] = s.Map.Ants.At(loc).Player()
+	}
+	me.territory.Update(sources)
 
-		ant.moveTarget = ant.exploreTarget
+	// Paths steer clear of enemies, recent deaths and enemy hills
+	me.profiler.Begin("paths")
+	me.paths.ClearLayers()
+	me.paths.AddLayer(me.paths.ThreatLayer(s.AttackRadius2, me.config.ThreatCost))
+	me.paths.AddLayer(DeathLayer(me.casualties, me.config.DeathCost))
+	me.paths.AddLayer(me.paths.HillLayer(me.knownHills, me.config.HillRadius2, me.config.HillCost))
+	me.paths.AddLayer(CorridorLayer(me.terrain, me.config.CorridorCost))
+/*
+	str := ""
+	for row := 0; row < me.config.Areas; row++ {
+	    for col := 0; col < me.config.Areas; col++ {
+	        str += fmt.Sprintf( "%d,", me.antCountArea[row*me.config.Areas+col] )
+	    }
+	    str += "\n"
 	}
-	
-	tryAnyMove := func (ant *Ant) {
-		for dir := Direction(0); dir < 4; dir++ {
-			if (safeMove(ant.loc, dir)) {
-				return
-			}
+	s.Log.Debugf("ants per area:\n%s", str)
+*/
+	// Shift ants between behaviors as the game goes on
+	me.profiler.Begin("phase")
+	enemyAnts := 0
+	for _, loc := range s.Map.Ants.Locations() {
+		if s.Map.Ants.At(loc).ToAnt() != MY_ANT {
+			enemyAnts++
 		}
 	}
-	
-	for _, ant := range me.ants {
-		if ant.state == STATE_EXPLORE {
-			if ant.moves == nil {
-				findNewTarget(ant)
-			}
-			if !nextBFSMove(ant, ant.moveTarget, false) {
-				findNewTarget(ant)
-				tryAnyMove(ant)
-			}
-		}
+	if me.phase.Update(me.strategy, s.Turn, s.Turns, len(me.ants), enemyAnts, len(me.knownHills)) {
+		s.Log.Infof("Entering the %s", me.phase.Phase)
 	}
 
-	// Hunting for food now, as we may have switched other ants into this state
-	for _, ant := range me.ants {
-		if ant.state == STATE_HUNT_FOOD {
-			// Move towards the food
-			if !nextBFSMove(ant, ant.closestFood, true) {
-				tryAnyMove(ant)
-			}
-		}
-	}
+	me.movesMade = me.movesMade[:0]
+	me.strategy.Run(me)
 
 	// Go through all the moves, and update the ant states
-	for _, ant := range movesMade {
+	me.profiler.Begin("commit")
+	for _, ant := range me.movesMade {
 		me.antCountArea[me.locToArea(ant.loc)]--;
 		me.antCountArea[me.locToArea(ant.target)]++;				
-		
-		me.ants[ant.target] = me.ants[ant.loc]
-		me.ants[ant.loc] = nil, false
-		ant.loc = ant.target
 	}
+	me.tracker.Commit(me.movesMade)
+	me.profiler.EndTurn(s.Turn)
 
-	log.Println(fmt.Sprintf( "Finished turn in %d ms", (time.Nanoseconds() - startTime) / 1000000.0))
+	s.Log.Infof("Finished turn in %d ms", (time.Nanoseconds() - startTime) / 1000000.0)
 	//returning an error will halt the whole program!
 	return nil
 }
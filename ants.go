@@ -2,10 +2,10 @@ package main
 
 import (
 	"os"
+	"io"
 	"bufio"
 	"strconv"
 	"strings"
-	"fmt"
 	"log"
 )
 
@@ -29,7 +29,11 @@ type State struct {
 	PlayerSeed    int64 //random player seed
 	Turn          int   //current turn number
 
-	Map *Map
+	Map    *Map
+	Orders *OrderBook
+	Log    *Logger //nil unless logging was asked for
+
+	Output io.Writer //where orders go, os.Stdout if nil
 }
 
 //Start takes the initial parameters from stdin
@@ -87,6 +91,7 @@ func (s *State) Start() os.Error {
 	}
 
 	s.Map = NewMap(s.Rows, s.Cols)
+	s.Orders = NewOrderBook(s.Map)
 
 	return nil
 }
@@ -98,7 +103,7 @@ func (s *State) Start() os.Error {
 func (s *State) Loop(b Bot, BetweenTurnWork func()) os.Error {
 
 	//indicate we're ready
-	os.Stdout.Write([]byte("go\n"))
+	s.output().Write([]byte("go\n"))
 
 	for {
 		line, err := stdin.ReadString('\n')
@@ -124,6 +129,7 @@ func (s *State) Loop(b Bot, BetweenTurnWork func()) os.Error {
 			BetweenTurnWork()
 
 			s.Map.Reset()
+			s.Orders.Reset()
 			continue
 		}
 
@@ -143,6 +149,7 @@ func (s *State) Loop(b Bot, BetweenTurnWork func()) os.Error {
 				log.Panicf("Turn number out of sync, expected %v got %v", s.Turn+1, turn)
 			}
 			s.Turn = turn
+			s.Log.SetTurn(turn)
 		case "f":
 			if len(words) < 3 {
 				log.Panicf("Invalid command format (not enough parameters for food): \"%s\"", line)
@@ -216,25 +223,31 @@ func (s *State) Loop(b Bot, BetweenTurnWork func()) os.Error {
 	return nil
 }
 
-//Call IssueOrderRowCol to issue an order for an ant at (Row, Col)
-func (s *State) IssueOrderRowCol(Row, Col int, d Direction) {
-	loc := s.Map.FromRowCol(Row, Col)
-	dest := s.Map.Move(loc, d)
-	s.Map.RemoveDestination(loc)
-	s.Map.AddDestination(dest)
-	fmt.Fprintf(os.Stdout, "o %d %d %s\n", Row, Col, d)
+//Call IssueOrderRowCol to issue an order for an ant at (Row, Col). Illegal
+//orders are rejected with an error and never sent.
+func (s *State) IssueOrderRowCol(Row, Col int, d Direction) os.Error {
+	return s.Orders.Add(s.Map.FromRowCol(Row, Col), d)
 }
 
-//Call IssueOrderLoc to issue an order for an ant at loc
-func (s *State) IssueOrderLoc(loc Location, d Direction) {
-	Row, Col := s.Map.FromLocation(loc)
-	dest := s.Map.Move(loc, d)
-	s.Map.RemoveDestination(loc)
-	s.Map.AddDestination(dest)
-	fmt.Fprintf(os.Stdout, "o %d %d %s\n", Row, Col, d)
+//Call IssueOrderLoc to issue an order for an ant at loc. Illegal orders are
+//rejected with an error and never sent.
+func (s *State) IssueOrderLoc(loc Location, d Direction) os.Error {
+	return s.Orders.Add(loc, d)
 }
 
 //endTurn is called by Loop, you don't need to call it.
 func (s *State) endTurn() {
-	os.Stdout.Write([]byte("go\n"))
+	for _, o := range s.Orders.Rejected {
+		s.Log.Ant(s.Map, o.Loc).Warnf("Rejected order %s: %s", o.Dir, o.Reason)
+	}
+	s.Orders.Flush(s.output())
+	s.output().Write([]byte("go\n"))
+}
+
+//output returns where orders should be written.
+func (s *State) output() io.Writer {
+	if s.Output == nil {
+		return os.Stdout
+	}
+	return s.Output
 }
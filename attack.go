@@ -0,0 +1,146 @@
+package main
+
+import (
+	"math"
+)
+
+//enemyClusters groups the enemy ants on the map into clusters of ants close
+//enough to back each other up in a fight, that is to attack the same square.
+func enemyClusters(m *Map, attackRadius2 int) [][]Location {
+	enemies := []Location{}
+	for _, loc := range m.Ants.Locations() {
+		if m.Ants.At(loc).ToAnt() != MY_ANT {
+			enemies = append(enemies, loc)
+		}
+	}
+
+	link2 := 4 * attackRadius2
+	clustered := make(map[Location]bool)
+	clusters := [][]Location{}
+	for _, start := range enemies {
+		if clustered[start] {
+			continue
+		}
+		clustered[start] = true
+		cluster := []Location{start}
+		for i := 0; i < len(cluster); i++ {
+			for _, other := range enemies {
+				if !clustered[other] && m.Distance2(cluster[i], other) <= link2 {
+					clustered[other] = true
+					cluster = append(cluster, other)
+				}
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+//AttackBehavior looks for enemy clusters we outnumber nearby, and sends the
+//ants that are close enough in to fight them. Ants only step up to the front
+//when the friends around them are at least a match for the enemies that
+//could hit them, so we arrive together and trade at least one for one.
+type AttackBehavior struct {
+	targets map[*Ant]Location //the enemy each attacker is going for
+	enemies []Location
+}
+
+func (b *AttackBehavior) Name() string {
+	return "attack"
+}
+
+func (b *AttackBehavior) Prepare(me *GarboAnt) {
+	s := me.state
+	b.targets = make(map[*Ant]Location)
+	b.enemies = b.enemies[:0]
+
+	reach := math.Sqrt(float64(s.AttackRadius2)) + float64(me.config.AttackReach)
+	reach2 := int(reach * reach)
+	for _, cluster := range enemyClusters(s.Map, s.AttackRadius2) {
+		b.enemies = append(b.enemies, cluster...)
+
+		// Count who can join the fight in a few moves
+		attackers := make(map[*Ant]Location)
+		for _, ant := range me.ants {
+			best, bestDist := Location(0), reach2+1
+			for _, enemy := range cluster {
+				if d := s.Map.Distance2(ant.loc, enemy); d < bestDist {
+					best, bestDist = enemy, d
+				}
+			}
+			if bestDist <= reach2 {
+				attackers[ant] = best
+			}
+		}
+		if float64(len(attackers)) < me.config.AttackRatio*float64(len(cluster)) {
+			continue
+		}
+		for ant, enemy := range attackers {
+			if old, taken := b.targets[ant]; !taken || s.Map.Distance2(ant.loc, enemy) < s.Map.Distance2(ant.loc, old) {
+				b.targets[ant] = enemy
+			}
+		}
+	}
+	if len(b.targets) > 0 {
+		s.Log.Debugf("%d ants attacking", len(b.targets))
+	}
+}
+
+func (b *AttackBehavior) Bid(me *GarboAnt, ant *Ant) float64 {
+	if _, attacking := b.targets[ant]; attacking {
+		return 2
+	}
+	return 0
+}
+
+//supported tells if an ant moving to dest would have at least as many of our
+//ants around it as there are enemies that could attack it there next turn.
+func (b *AttackBehavior) supported(me *GarboAnt, ant *Ant, dest Location) bool {
+	s := me.state
+	reach := math.Sqrt(float64(s.AttackRadius2)) + 1
+	reach2 := int(reach * reach)
+	enemies := 0
+	for _, enemy := range b.enemies {
+		if s.Map.Distance2(dest, enemy) <= reach2 {
+			enemies++
+		}
+	}
+	friends := 1
+	for loc, other := range me.ants {
+		if other != ant && s.Map.Distance2(dest, loc) <= reach2 {
+			friends++
+		}
+	}
+	return friends >= enemies
+}
+
+func (b *AttackBehavior) Move(me *GarboAnt, ant *Ant) {
+	s := me.state
+	target := b.targets[ant]
+
+	// Close in on the target, but don't run ahead of the others
+	if dir := me.fightStep(ant, target); dir != NoMovement {
+		dest := s.Map.Move(ant.loc, dir)
+		if s.Map.SafeDestination(dest) && b.supported(me, ant, dest) && me.safeMove(ant.loc, dir) {
+			ant.moves = nil
+			return
+		}
+	}
+
+	// The way there is blocked, so get as close as the others let us
+	best, bestDist := NoMovement, s.Map.Distance2(ant.loc, target)
+	for dir := North; dir <= West; dir++ {
+		dest := s.Map.Move(ant.loc, dir)
+		if !s.Map.SafeDestination(dest) {
+			continue
+		}
+		if d := s.Map.Distance2(dest, target); d < bestDist && b.supported(me, ant, dest) {
+			best, bestDist = dir, d
+		}
+	}
+	if best != NoMovement {
+		me.safeMove(ant.loc, best)
+	}
+	// The path the ant was on doesn't lead here any more
+	ant.moves = nil
+}
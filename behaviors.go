@@ -0,0 +1,231 @@
+package main
+
+import (
+	"rand"
+)
+
+//FoodBehavior sends ants after the food the economy says is worth having,
+//and to ambush enemies going for food they'll get to first.
+type FoodBehavior struct{}
+
+func (b *FoodBehavior) Name() string {
+	return "food"
+}
+
+func (b *FoodBehavior) Prepare(me *GarboAnt) {
+	me.economy.Update()
+
+	// Keep up with how the race for each ant's food is going
+	for _, ant := range me.ants {
+		if ant.state != STATE_HUNT_FOOD && ant.state != STATE_AMBUSH {
+			continue
+		}
+		fv := me.economy.Food(ant.closestFood)
+		switch {
+		case fv == nil || fv.Contest == CONTEST_ABANDON:
+			// The food has gone or we'll lose it, switch back to exploring
+			ant.state = STATE_EXPLORE
+			me.foodHunted[ant.closestFood] = nil, false
+		case fv.Contest == CONTEST_AMBUSH:
+			ant.state = STATE_AMBUSH
+		default:
+			ant.state = STATE_HUNT_FOOD
+		}
+	}
+
+	// The most valuable food goes to the nearest ant that isn't busy
+	free := func(loc Location) bool {
+		ant := me.ants[loc]
+		return ant != nil && ant.state == STATE_EXPLORE
+	}
+	for _, fv := range me.economy.Foods {
+		if fv.Value == 0 && fv.Contest != CONTEST_AMBUSH {
+			continue
+		}
+		if hunter := me.foodHunted[fv.Loc]; hunter != nil && hunter.state != STATE_EXPLORE && hunter.closestFood == fv.Loc {
+			continue
+		}
+		loc, dist := fv.Gatherer, fv.Distance
+		if !free(loc) {
+			loc, dist = me.economy.Nearest(fv.Loc, free)
+		}
+		if dist < 0 {
+			continue
+		}
+		// Further off than the gatherer, an enemy may beat us to it
+		if fv.Contest != CONTEST_AMBUSH && fv.EnemyDistance >= 0 && fv.EnemyDistance < dist {
+			continue
+		}
+		ant := me.ants[loc]
+		ant.state = STATE_HUNT_FOOD
+		if fv.Contest == CONTEST_AMBUSH {
+			ant.state = STATE_AMBUSH
+		}
+		ant.closestFood = fv.Loc
+		me.foodHunted[fv.Loc] = ant
+	}
+}
+
+func (b *FoodBehavior) Bid(me *GarboAnt, ant *Ant) float64 {
+	if ant.state == STATE_HUNT_FOOD || ant.state == STATE_AMBUSH {
+		return 1
+	}
+	return 0
+}
+
+func (b *FoodBehavior) Move(me *GarboAnt, ant *Ant) {
+	// Lie in wait for the enemy that's going to get the food first
+	if ant.state == STATE_AMBUSH {
+		spot := me.economy.Food(ant.closestFood).Ambush
+		if ant.loc != spot && !me.nextBFSMove(ant, spot, true) {
+			me.tryAnyMove(ant)
+		}
+		return
+	}
+
+	// Move towards the food
+	if !me.nextBFSMove(ant, ant.closestFood, true) {
+		me.tryAnyMove(ant)
+	}
+}
+
+//Random explore targets are picked up to this many times over until one is
+//reachable.
+const EXPLORE_TRIES = 10
+
+//ExploreBehavior sends the ants that have nothing better to do to the edge
+//of the known map.
+type ExploreBehavior struct {
+	targets map[Location]Location //frontier squares handed out this turn
+	border  []Location            //edge of our territory, for explorers without a frontier square
+	claimed map[Location]bool     //border squares somebody is already headed to
+	random  bool                  //ignore the frontier and pick targets at random
+}
+
+func (b *ExploreBehavior) Name() string {
+	return "explore"
+}
+
+func (b *ExploreBehavior) Prepare(me *GarboAnt) {
+	if b.random {
+		b.targets = make(map[Location]Location)
+		b.border = nil
+		return
+	}
+
+	// Explorers that need a new target get a frontier cluster of their own
+	myAnts := []Location{}
+	explorers := []Location{}
+	taken := []Location{}
+	for loc, ant := range me.ants {
+		myAnts = append(myAnts, loc)
+		if ant.state != STATE_EXPLORE {
+			continue
+		}
+		if ant.moves == nil {
+			explorers = append(explorers, loc)
+		} else {
+			taken = append(taken, ant.exploreTarget)
+		}
+	}
+	me.frontier.Update(me.state.Map, me.state.Turn, myAnts)
+	b.targets = me.frontier.Assign(explorers, taken)
+
+	// Nothing left to uncover nearby means holding the edge of our territory
+	b.border = me.territory.FrontierOf(MY_ANT.Player())
+	b.claimed = make(map[Location]bool)
+	for _, loc := range taken {
+		b.claimed[loc] = true
+	}
+}
+
+func (b *ExploreBehavior) Bid(me *GarboAnt, ant *Ant) float64 {
+	if ant.state == STATE_EXPLORE {
+		return 1
+	}
+	return 0
+}
+
+func (b *ExploreBehavior) Move(me *GarboAnt, ant *Ant) {
+	if ant.moves == nil {
+		b.findNewTarget(me, ant)
+	}
+	if !me.nextBFSMove(ant, ant.moveTarget, false) {
+		b.findNewTarget(me, ant)
+		me.tryAnyMove(ant)
+	}
+}
+
+func (b *ExploreBehavior) findNewTarget(me *GarboAnt, ant *Ant) {
+	s := me.state
+
+	// A frontier target is only good once, if we can't get there pick at random
+	if target, assigned := b.targets[ant.loc]; assigned {
+		b.targets[ant.loc] = 0, false
+		ant.exploreTarget = target
+		ant.moveTarget = target
+		return
+	}
+
+	// Next best is the nearest bit of our border nobody else is covering
+	if target, found := b.nearestBorder(me, ant); found {
+		b.claimed[target] = true
+		ant.exploreTarget = target
+		ant.moveTarget = target
+		return
+	}
+
+	areas := me.config.Areas
+	wrap := areas*areas
+
+	// Pick again if it's somewhere we know we can't get to
+	for tries := 0; tries < EXPLORE_TRIES; tries++ {
+		bestArea := rand.Intn(wrap)
+
+		// Now, path there
+		ant.exploreTarget = me.areaToLoc(bestArea)
+		row, col := s.Map.FromLocation(ant.exploreTarget)
+		w := s.Map.Cols / areas
+		h := s.Map.Rows / areas
+		if w < 1 || h < 1 {
+			w, h = 1, 1 // more areas than squares on small maps
+		}
+		row = row + rand.Intn(w) - (w / 2)
+		col = col + rand.Intn(h) - (h / 2)
+		ant.exploreTarget = s.Map.FromRowCol(row, col)
+		for me.knownWater[ant.exploreTarget] {
+			for _, dir := range me.waterEscape {
+				ant.exploreTarget = s.Map.Move(ant.exploreTarget, dir)
+			}
+		}
+		// Nor is there any point going where the map's symmetry says is water
+		item, confidence := me.symmetry.PredictItem(ant.exploreTarget)
+		if item == WATER && confidence >= float32(me.config.MinWaterConfidence) {
+			continue
+		}
+		if me.regions.Reachable(ant.loc, ant.exploreTarget) {
+			break
+		}
+	}
+
+	ant.moveTarget = ant.exploreTarget
+}
+
+//nearestBorder returns the unclaimed square of our territory's frontier
+//closest to ant that it can get to.
+func (b *ExploreBehavior) nearestBorder(me *GarboAnt, ant *Ant) (Location, bool) {
+	best, bestDist := Location(0), -1
+	for _, loc := range b.border {
+		if loc == ant.loc || b.claimed[loc] {
+			continue
+		}
+		dist := me.state.Map.Distance2(ant.loc, loc)
+		if bestDist >= 0 && dist >= bestDist {
+			continue
+		}
+		if me.regions.Reachable(ant.loc, loc) {
+			best, bestDist = loc, dist
+		}
+	}
+	return best, bestDist >= 0
+}
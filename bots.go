@@ -0,0 +1,128 @@
+package main
+
+import (
+	"log"
+	"os"
+	"rand"
+	"sort"
+	"strings"
+)
+
+//botFactories holds every bot that can be picked with -bot, by name.
+var botFactories = make(map[string]func(s *State, c *Config) Bot)
+
+//RegisterBot makes a bot available under name.
+func RegisterBot(name string, factory func(s *State, c *Config) Bot) {
+	if _, exists := botFactories[name]; exists {
+		log.Panicf("bot %s registered twice", name)
+	}
+	botFactories[name] = factory
+}
+
+//BotNames returns the names of all the registered bots in sorted order.
+func BotNames() []string {
+	names := []string{}
+	for name := range botFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//MakeBot constructs the bot registered under name, configured by c. The
+//random numbers the bot uses are seeded from the engine's player seed, so a
+//seeded game plays out the same every time.
+func MakeBot(name string, s *State, c *Config) (Bot, os.Error) {
+	factory, exists := botFactories[name]
+	if !exists {
+		return nil, os.NewError("unknown bot " + name + ", expected one of " + strings.Join(BotNames(), ", "))
+	}
+	rand.Seed(s.PlayerSeed)
+	return factory(s, c), nil
+}
+
+func init() {
+	RegisterBot("garbo", NewBot)
+	RegisterBot("garbo-random", func(s *State, c *Config) Bot {
+		//GarboAnt exploring the way it used to, without the frontier
+		me := NewBot(s, c).(*GarboAnt)
+		me.strategy.Behavior("explore").(*ExploreBehavior).random = true
+		return me
+	})
+	RegisterBot("random", NewRandomBot)
+	RegisterBot("greedy", NewGreedyBot)
+}
+
+//RandomBot moves every ant in a random direction. It's the baseline every
+//other bot should beat.
+type RandomBot struct{}
+
+func NewRandomBot(s *State, c *Config) Bot {
+	return &RandomBot{}
+}
+
+func (b *RandomBot) DoTurn(s *State) os.Error {
+	for _, loc := range s.Map.Ants.Locations() {
+		if s.Map.Ants.At(loc) != MY_ANT {
+			continue
+		}
+		for _, i := range rand.Perm(4) {
+			if s.IssueOrderLoc(loc, Direction(i)) == nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+//GreedyBot steps every ant towards the nearest food it knows about, as the
+//crow flies, and moves randomly when there isn't any.
+type GreedyBot struct{}
+
+func NewGreedyBot(s *State, c *Config) Bot {
+	return &GreedyBot{}
+}
+
+func (b *GreedyBot) DoTurn(s *State) os.Error {
+	for _, loc := range s.Map.Ants.Locations() {
+		if s.Map.Ants.At(loc) != MY_ANT {
+			continue
+		}
+		row, col := s.Map.FromLocation(loc)
+
+		bestDist := -1
+		bestRow, bestCol := 0, 0
+		for _, food := range s.Map.Food.Locations() {
+			frow, fcol := s.Map.FromLocation(food)
+			dr := wrapDelta(row, frow, s.Map.Rows)
+			dc := wrapDelta(col, fcol, s.Map.Cols)
+			dist := dr*dr + dc*dc
+			if bestDist == -1 || dist < bestDist {
+				bestDist = dist
+				bestRow, bestCol = dr, dc
+			}
+		}
+
+		dirs := []Direction{}
+		if bestRow < 0 {
+			dirs = append(dirs, North)
+		} else if bestRow > 0 {
+			dirs = append(dirs, South)
+		}
+		if bestCol < 0 {
+			dirs = append(dirs, West)
+		} else if bestCol > 0 {
+			dirs = append(dirs, East)
+		}
+		for _, i := range rand.Perm(4) {
+			dirs = append(dirs, Direction(i))
+		}
+
+		for _, dir := range dirs {
+			if s.IssueOrderLoc(loc, dir) == nil {
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+type DeathCause int
+
+const (
+	DEATH_UNKNOWN   DeathCause = iota
+	DEATH_COMBAT               //enemies were in attack range
+	DEATH_COLLISION            //ran into another ant
+	DEATH_RAZING               //died at an enemy hill with no enemy seen
+	NUM_DEATH_CAUSES
+)
+
+var deathCauseNames = []string{"unknown", "combat", "collision", "razing"}
+
+func (c DeathCause) String() string {
+	if c < 0 || c >= NUM_DEATH_CAUSES {
+		return fmt.Sprintf("cause%d", int(c))
+	}
+	return deathCauseNames[c]
+}
+
+//Casualty is one of our ants dying.
+type Casualty struct {
+	Loc   Location
+	Turn  int
+	Cause DeathCause
+}
+
+//Casualties works out why our ants die from the dead ants the server
+//reports, and keeps a map of where we've been losing them. Every death adds
+//danger to the squares in attack range of it, and danger fades by a factor
+//each turn, so the danger zones follow where the fighting is now.
+type Casualties struct {
+	m             *Map
+	attackRadius2 int
+	reachRadius2  int //attack range once both sides have made a move
+	decay         float32
+	threshold     float32
+
+	//enemies seen last turn, for the deaths nobody saw happen
+	lastEnemies *LocationSet
+
+	Danger  []float32 //per square
+	Losses  []int     //per square, over the whole game
+	ByCause []int     //deaths so far, indexed by DeathCause
+	Total   int
+}
+
+//NewCasualties returns an analyzer for m. Danger fades by decay each turn,
+//and squares with at least threshold of it are danger zones.
+func NewCasualties(m *Map, attackRadius2 int, decay, threshold float64) *Casualties {
+	reach := math.Sqrt(float64(attackRadius2)) + 2
+	return &Casualties{
+		m:             m,
+		attackRadius2: attackRadius2,
+		reachRadius2:  int(reach * reach),
+		lastEnemies:   NewLocationSet(m.Rows * m.Cols),
+		decay:         float32(decay),
+		threshold:     float32(threshold),
+		Danger:        make([]float32, m.Rows*m.Cols),
+		Losses:        make([]int, m.Rows*m.Cols),
+		ByCause:       make([]int, NUM_DEATH_CAUSES),
+	}
+}
+
+//Update attributes the deaths of this turn. died are the ants the tracker
+//lost, where they were expected to be; Map.Dead tells us what else died, and
+//enemyHills are the hills we know of.
+func (ca *Casualties) Update(turn int, died []*Ant, enemyHills map[Location]Item) []Casualty {
+	for i := range ca.Danger {
+		ca.Danger[i] *= ca.decay
+	}
+
+	lostAt := make(map[Location]int)
+	for _, ant := range died {
+		lostAt[ant.loc]++
+	}
+
+	casualties := []Casualty{}
+	for _, ant := range died {
+		cause := ca.cause(ant.loc, lostAt[ant.loc], enemyHills)
+		casualties = append(casualties, Casualty{ant.loc, turn, cause})
+		ca.ByCause[cause]++
+		ca.Total++
+		ca.Losses[ant.loc]++
+		ca.m.DoInRad(ant.loc, ca.attackRadius2, func(row, col int) {
+			ca.Danger[ca.m.FromRowCol(row, col)] += 1
+		})
+	}
+
+	ca.lastEnemies.Clear()
+	for _, loc := range ca.m.Ants.Locations() {
+		if ca.m.Ants.At(loc).ToAnt() != MY_ANT {
+			ca.lastEnemies.Add(loc)
+		}
+	}
+	return casualties
+}
+
+//cause guesses what killed an ant of ours at loc, lost being how many of our
+//ants we lost there.
+func (ca *Casualties) cause(loc Location, lost int, enemyHills map[Location]Item) DeathCause {
+	//ants only ever die on someone else's square by walking into it
+	dead := ca.m.Dead.At(loc)
+	if lost > 1 || (dead.IsAnt() && dead.ToAnt() != MY_ANT) {
+		return DEATH_COLLISION
+	}
+
+	enemies := 0
+	ca.m.DoInRad(loc, ca.attackRadius2, func(row, col int) {
+		near := ca.m.FromRowCol(row, col)
+		if ant := ca.m.Ants.At(near); ant.IsAnt() && ant.ToAnt() != MY_ANT {
+			enemies++
+		}
+		if ant := ca.m.Dead.At(near); ant.IsAnt() && ant.ToAnt() != MY_ANT {
+			enemies++
+		}
+	})
+	if enemies == 0 {
+		//the dead often can't be seen by anyone left alive, so fall back
+		//on the enemies that could have closed in since last turn
+		ca.m.DoInRad(loc, ca.reachRadius2, func(row, col int) {
+			if ca.lastEnemies.Has(ca.m.FromRowCol(row, col)) {
+				enemies++
+			}
+		})
+	}
+	if enemies > 0 {
+		return DEATH_COMBAT
+	}
+
+	//with nobody about, dying on or next to a hill is down to its defenders
+	//hatching or coming back out of sight
+	for hill := range enemyHills {
+		if ca.m.Distance2(loc, hill) <= 2 {
+			return DEATH_RAZING
+		}
+	}
+	return DEATH_UNKNOWN
+}
+
+//IsDangerous tells if loc is in a danger zone.
+func (ca *Casualties) IsDangerous(loc Location) bool {
+	return ca.Danger[loc] >= ca.threshold
+}
+
+//DangerZones returns the squares in danger zones.
+func (ca *Casualties) DangerZones() []Location {
+	zones := []Location{}
+	for loc, danger := range ca.Danger {
+		if danger >= ca.threshold {
+			zones = append(zones, Location(loc))
+		}
+	}
+	return zones
+}
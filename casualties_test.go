@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCasualties(t *testing.T) {
+	m := NewMap(20, 20)
+	ca := NewCasualties(m, 5, 0.5, 1)
+
+	combat := &Ant{loc: m.FromRowCol(2, 2)}
+	m.AddAnt(m.FromRowCol(2, 4), ANT_1)
+	collision := &Ant{loc: m.FromRowCol(10, 10)}
+	m.AddDeadAnt(collision.loc, ANT_2)
+	razing := &Ant{loc: m.FromRowCol(15, 3)}
+	defended := &Ant{loc: m.FromRowCol(15, 16)}
+	m.AddAnt(m.FromRowCol(16, 17), ANT_2)
+	hills := map[Location]Item{m.FromRowCol(15, 4): HILL_1, m.FromRowCol(15, 17): HILL_2}
+	unknown := &Ant{loc: m.FromRowCol(5, 15)}
+
+	casualties := ca.Update(1, []*Ant{combat, collision, razing, defended, unknown}, hills)
+	want := []DeathCause{DEATH_COMBAT, DEATH_COLLISION, DEATH_RAZING, DEATH_COMBAT, DEATH_UNKNOWN}
+	for i, c := range casualties {
+		if c.Cause != want[i] {
+			t.Errorf("death at %v put down to %s, want %s", c.Loc, c.Cause, want[i])
+		}
+	}
+	if ca.Total != 5 || ca.ByCause[DEATH_COMBAT] != 2 {
+		t.Errorf("counted %d deaths, %d in combat", ca.Total, ca.ByCause[DEATH_COMBAT])
+	}
+
+	if !ca.IsDangerous(combat.loc) || !ca.IsDangerous(m.FromRowCol(3, 3)) {
+		t.Errorf("no danger zone around a death")
+	}
+	if ca.IsDangerous(m.FromRowCol(8, 2)) {
+		t.Errorf("danger zone far from any death")
+	}
+	m.Reset()
+	ca.Update(2, nil, hills)
+	if ca.IsDangerous(combat.loc) {
+		t.Errorf("danger zone didn't fade")
+	}
+	if ca.Losses[combat.loc] != 1 {
+		t.Errorf("losses forgotten")
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"json"
+	"os"
+)
+
+//Config holds every strategy knob for GarboAnt, so they can be tuned from a
+//file without recompiling. Fields left out of the file keep their defaults.
+type Config struct {
+	Areas       int    //explore targets are picked from an Areas x Areas grid over the map
+	WaterEscape string //moves ("n", "e", "s", "w") repeated to walk a target out of water
+
+	MinHillConfidence   float64 //predicted hills below this confidence are ignored
+	MinWaterConfidence  float64 //explore targets predicted to be water with this confidence are skipped
+	SymmetryBudget      int     //square checks the symmetry detector may do per turn
+	SymmetryHillSupport int     //terrain support a hill sighting is worth to a symmetry
+
+	StaleTurns          int //turns after which a square counts as unexplored again
+	MaxFrontierClusters int //only the largest frontier clusters are handed out
+
+	DangerDecay     float64 //danger left in a square after a turn, as a fraction
+	DangerThreshold float64 //danger at which a square counts as a danger zone
+
+	FoodMaxDistance     int     //steps from food within which ants are looked for
+	FoodDiscount        float64 //food loses this fraction of its value per step away
+	FoodContestedFactor float64 //value kept by food an enemy can get to as soon as us
+	ContestRadius2      int     //ants within this of food, squared, count in a fight for it
+
+	ThreatCost  float64 //path cost of a square per enemy that can attack it next turn
+	DeathCost   float64 //path cost of a square per unit of danger in it
+	HillCost    float64 //path cost of a square near an enemy hill
+	HillRadius2 int     //how near, squared, counts as near an enemy hill
+
+	CorridorWidth int     //passages this many squares wide or narrower are corridors
+	CorridorCost  float64 //path cost of a square in a corridor
+
+	AttackReach int     //moves away from an enemy cluster our ants count toward a fight
+	AttackRatio float64 //we attack a cluster with at least this many ants per enemy
+
+	MaxSkirmishAnts    int     //fights with more ants than this aren't searched
+	SkirmishTime       float64 //fraction of the turn time the skirmish searches may take
+	SkirmishLossWeight float64 //how much worse losing an ant is than killing one
+
+	SquadMinSize int //fewest ants a squad is formed with or kept together with
+	SquadMaxSize int //most ants a squad is formed with
+
+	OpeningAnts    int     //ants it takes to end the opening
+	MidgameAnts    int     //ants it takes to start the midgame without an enemy hill in sight
+	EndgameTurns   float64 //fraction of the game's turns after which it's the endgame
+	DominanceRatio float64 //our ants per visible enemy that make it the endgame early
+	DefendRadius2  int     //enemies within this of our hill, squared, get defended against
+	RaidRadius2    int     //an ant this far from an enemy hill, squared, bids half as much to raid it
+
+	PhaseWeights [NUM_PHASES]PhaseWeights //bid weights in each phase of the game
+
+	SkirmishPriority int
+	SquadPriority    int
+	AttackPriority   int
+	DefendPriority   int
+	RaidPriority     int
+	FoodPriority     int
+	ExplorePriority  int
+}
+
+//DefaultConfig returns the configuration GarboAnt plays with out of the box.
+func DefaultConfig() *Config {
+	c := &Config{
+		Areas:       12,
+		WaterEscape: "nne",
+
+		MinHillConfidence:   0.05,
+		MinWaterConfidence:  0.5,
+		SymmetryBudget:      1000000,
+		SymmetryHillSupport: 50,
+
+		StaleTurns:          30,
+		MaxFrontierClusters: 20,
+
+		DangerDecay:     0.95,
+		DangerThreshold: 1,
+
+		FoodMaxDistance:     12,
+		FoodDiscount:        0.9,
+		FoodContestedFactor: 0.5,
+		ContestRadius2:      50,
+
+		ThreatCost:  4,
+		DeathCost:   2,
+		HillCost:    2,
+		HillRadius2: 20,
+
+		CorridorWidth: 3,
+		CorridorCost:  0.5,
+
+		AttackReach: 3,
+		AttackRatio: 1.5,
+
+		MaxSkirmishAnts:    8,
+		SkirmishTime:       0.3,
+		SkirmishLossWeight: 1.2,
+
+		SquadMinSize: 3,
+		SquadMaxSize: 6,
+
+		OpeningAnts:    10,
+		MidgameAnts:    40,
+		EndgameTurns:   0.85,
+		DominanceRatio: 4,
+		DefendRadius2:  100,
+		RaidRadius2:    400,
+
+		PhaseWeights: [NUM_PHASES]PhaseWeights{
+			PHASE_OPENING:   {Food: 2, Explore: 1, Defend: 1, Raid: 0},
+			PHASE_EXPANSION: {Food: 2, Explore: 1, Defend: 1, Raid: 0.5},
+			PHASE_MIDGAME:   {Food: 2, Explore: 0.8, Defend: 1, Raid: 1.5},
+			PHASE_ENDGAME:   {Food: 1, Explore: 0.5, Defend: 1, Raid: 4},
+		},
+
+		SkirmishPriority: 40,
+		SquadPriority:    35,
+		AttackPriority:   30,
+		DefendPriority:   27,
+		RaidPriority:     25,
+		FoodPriority:     20,
+		ExplorePriority:  10,
+	}
+	if err := c.Validate(); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+//LoadConfig reads a JSON config file over the defaults and validates it.
+func LoadConfig(fname string) (*Config, os.Error) {
+	c := DefaultConfig()
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %s", fname, err)
+	}
+	if err = c.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", fname, err)
+	}
+	return c, nil
+}
+
+//Validate checks that every knob is in range.
+func (c *Config) Validate() os.Error {
+	switch {
+	case c.Areas <= 0:
+		return os.NewError("Areas must be positive")
+	case c.MinHillConfidence < 0 || c.MinHillConfidence > 1:
+		return os.NewError("MinHillConfidence must be between 0 and 1")
+	case c.MinWaterConfidence < 0 || c.MinWaterConfidence > 1:
+		return os.NewError("MinWaterConfidence must be between 0 and 1")
+	case c.SymmetryBudget <= 0:
+		return os.NewError("SymmetryBudget must be positive")
+	case c.SymmetryHillSupport < 0:
+		return os.NewError("SymmetryHillSupport can't be negative")
+	case c.StaleTurns < 0:
+		return os.NewError("StaleTurns can't be negative")
+	case c.MaxFrontierClusters <= 0:
+		return os.NewError("MaxFrontierClusters must be positive")
+	case c.DangerDecay < 0 || c.DangerDecay > 1:
+		return os.NewError("DangerDecay must be between 0 and 1")
+	case c.DangerThreshold <= 0:
+		return os.NewError("DangerThreshold must be positive")
+	case c.FoodMaxDistance <= 0:
+		return os.NewError("FoodMaxDistance must be positive")
+	case c.FoodDiscount <= 0 || c.FoodDiscount > 1:
+		return os.NewError("FoodDiscount must be above 0 and at most 1")
+	case c.FoodContestedFactor < 0 || c.FoodContestedFactor > 1:
+		return os.NewError("FoodContestedFactor must be between 0 and 1")
+	case c.ContestRadius2 < 0:
+		return os.NewError("ContestRadius2 can't be negative")
+	case c.ThreatCost < 0 || c.DeathCost < 0 || c.HillCost < 0 || c.CorridorCost < 0:
+		return os.NewError("path costs can't be negative")
+	case c.HillRadius2 < 0:
+		return os.NewError("HillRadius2 can't be negative")
+	case c.CorridorWidth < 1:
+		return os.NewError("CorridorWidth must be at least 1")
+	case c.AttackReach < 0:
+		return os.NewError("AttackReach can't be negative")
+	case c.AttackRatio <= 0:
+		return os.NewError("AttackRatio must be positive")
+	case c.MaxSkirmishAnts < 2:
+		return os.NewError("MaxSkirmishAnts must be at least 2")
+	case c.SkirmishTime <= 0 || c.SkirmishTime > 1:
+		return os.NewError("SkirmishTime must be above 0 and at most 1")
+	case c.SkirmishLossWeight <= 0:
+		return os.NewError("SkirmishLossWeight must be positive")
+	case c.SquadMinSize < 2:
+		return os.NewError("SquadMinSize must be at least 2")
+	case c.SquadMaxSize < c.SquadMinSize:
+		return os.NewError("SquadMaxSize can't be less than SquadMinSize")
+	case c.OpeningAnts < 0:
+		return os.NewError("OpeningAnts can't be negative")
+	case c.MidgameAnts < c.OpeningAnts:
+		return os.NewError("MidgameAnts can't be less than OpeningAnts")
+	case c.EndgameTurns < 0 || c.EndgameTurns > 1:
+		return os.NewError("EndgameTurns must be between 0 and 1")
+	case c.DominanceRatio <= 0:
+		return os.NewError("DominanceRatio must be positive")
+	case c.DefendRadius2 < 0:
+		return os.NewError("DefendRadius2 can't be negative")
+	case c.RaidRadius2 <= 0:
+		return os.NewError("RaidRadius2 must be positive")
+	}
+	for phase, w := range c.PhaseWeights {
+		if w.Food < 0 || w.Explore < 0 || w.Defend < 0 || w.Raid < 0 {
+			return fmt.Errorf("%s weights can't be negative", GamePhase(phase))
+		}
+	}
+
+	_, err := c.WaterEscapeMoves()
+	return err
+}
+
+//WaterEscapeMoves returns the moves WaterEscape spells out, or an error if it
+//doesn't spell out moves that get anywhere.
+func (c *Config) WaterEscapeMoves() ([]Direction, os.Error) {
+	moves := []Direction{}
+	rows, cols := 0, 0
+	for _, ch := range c.WaterEscape {
+		dir, err := ParseDirection(string(ch))
+		if err != nil {
+			return nil, fmt.Errorf("WaterEscape: %s", err)
+		}
+		switch dir {
+		case North:
+			rows--
+		case South:
+			rows++
+		case East:
+			cols++
+		case West:
+			cols--
+		}
+		moves = append(moves, dir)
+	}
+	if rows == 0 && cols == 0 {
+		//it would walk in circles forever
+		return nil, os.NewError("WaterEscape has to get somewhere")
+	}
+	return moves, nil
+}
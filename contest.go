@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+//Contest is what we do about food an enemy can get to.
+type Contest int
+
+const (
+	CONTEST_NONE    Contest = iota //no enemy gets there as soon as we do
+	CONTEST_RACE                   //an enemy can tie, go anyway so they don't get it either
+	CONTEST_AMBUSH                 //an enemy gets there first but we outnumber them around it
+	CONTEST_ABANDON                //an enemy gets there first and would win the fight
+)
+
+var contestNames = []string{"none", "race", "ambush", "abandon"}
+
+func (c Contest) String() string {
+	if c < CONTEST_NONE || c > CONTEST_ABANDON {
+		return fmt.Sprintf("contest%d", int(c))
+	}
+	return contestNames[c]
+}
+
+//contest floods out from the food to compare when each enemy gets there with
+//when we do, and when any of them wins counts the ants on each side that can
+//get there within the contest radius in steps, along with every enemy that
+//beats us to it. It overwrites the scratch space of the searches.
+func (fe *FoodEconomy) contest(fv *FoodValue) Contest {
+	if fv.EnemyDistance < 0 || fv.EnemyDistance > fv.Distance {
+		return CONTEST_NONE
+	}
+	steps := int(math.Sqrt(float64(fe.contestRadius2)))
+	fe.flood([]Location{fv.Loc})
+	ahead, tied, mine, enemies := 0, 0, 0, 0
+	for _, loc := range fe.queue {
+		ant := fe.m.Ants.At(loc)
+		if !ant.IsAnt() {
+			continue
+		}
+		d := fe.dist[loc]
+		if ant.ToAnt() == MY_ANT {
+			if d <= steps {
+				mine++
+			}
+			continue
+		}
+		switch {
+		case d < fv.Distance:
+			ahead++
+		case d == fv.Distance:
+			tied++
+		}
+		if d <= steps || d < fv.Distance {
+			enemies++
+		}
+	}
+	switch {
+	case ahead == 0 && tied == 0:
+		return CONTEST_NONE
+	case ahead == 0:
+		return CONTEST_RACE
+	case mine > enemies:
+		return CONTEST_AMBUSH
+	}
+	return CONTEST_ABANDON
+}
+
+//ambushSpot picks the square on the way from the gatherer to the food that is
+//about attack range from it, so we're ready to strike whoever comes to eat.
+//It overwrites the scratch space of the searches.
+func (fe *FoodEconomy) ambushSpot(fv *FoodValue) Location {
+	keep := int(math.Sqrt(float64(fe.attackRadius2))) + 1
+	fe.flood([]Location{fv.Loc})
+	spot := fv.Gatherer
+	for fe.dist[spot] > keep {
+		next := spot
+		for dir := North; dir <= West; dir++ {
+			loc := fe.m.Move(spot, dir)
+			if fe.reached[loc] == fe.search && fe.dist[loc] < fe.dist[next] {
+				next = loc
+			}
+		}
+		if next == spot {
+			break
+		}
+		spot = next
+	}
+	return spot
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+//FoodValue is what one piece of food is worth to us this turn.
+type FoodValue struct {
+	Loc           Location
+	Gatherer      Location //our nearest ant
+	Distance      int      //steps for Gatherer to get there, -1 if none of ours is in reach
+	EnemyDistance int      //steps for the nearest enemy, -1 if none is in reach
+	Value         float64  //0 for food we should leave alone
+	Contest       Contest
+	Ambush        Location //where to wait for the enemy, for CONTEST_AMBUSH
+}
+
+//Contested tells if an enemy can get to the food as soon as we can. If it
+//gets there first the food is theirs, and if it's a tie the food is destroyed.
+func (fv *FoodValue) Contested() bool {
+	return fv.EnemyDistance >= 0 && fv.EnemyDistance <= fv.Distance
+}
+
+type byValue []*FoodValue
+
+func (f byValue) Len() int           { return len(f) }
+func (f byValue) Less(i, j int) bool { return f[i].Value > f[j].Value }
+func (f byValue) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+//FoodEconomy values the food we can see. Every piece eaten is a new ant at
+//one of our hills, and the sooner we get it the sooner the ant hatches, so
+//food is worth discount to the power of the steps our nearest ant needs to
+//get there. Food an enemy gets to first is worth nothing, and food an enemy
+//can tie for is discounted again by contested. Which hill the new ant
+//hatches at is up to the server, which fills the free hills in its own
+//order, so there's no choosing food by the hill it feeds.
+type FoodEconomy struct {
+	m              *Map
+	attackRadius2  int
+	maxDist        int
+	contestRadius2 int
+	discount       float64
+	contested      float64
+
+	Foods []*FoodValue //highest value first
+	byLoc map[Location]*FoodValue
+
+	//scratch for the searches, reused by bumping search
+	search  int32
+	reached []int32
+	dist    []int
+	origin  []Location
+	queue   []Location
+}
+
+//NewFoodEconomy returns an economy for m that looks maxDist steps around
+//each piece of food, and weighs up fights for it within contestRadius2.
+func NewFoodEconomy(m *Map, attackRadius2, maxDist, contestRadius2 int, discount, contested float64) *FoodEconomy {
+	size := m.Rows * m.Cols
+	return &FoodEconomy{
+		m:              m,
+		attackRadius2:  attackRadius2,
+		maxDist:        maxDist,
+		contestRadius2: contestRadius2,
+		discount:       discount,
+		contested:      contested,
+		byLoc:          make(map[Location]*FoodValue),
+		reached:        make([]int32, size),
+		dist:           make([]int, size),
+		origin:         make([]Location, size),
+	}
+}
+
+//flood runs a BFS from all of sources at once up to maxDist steps, leaving
+//the distance to and the nearest of the sources for every square it reaches.
+func (fe *FoodEconomy) flood(sources []Location) {
+	fe.search++
+	fe.queue = fe.queue[:0]
+	for _, loc := range sources {
+		fe.reached[loc] = fe.search
+		fe.dist[loc] = 0
+		fe.origin[loc] = loc
+		fe.queue = append(fe.queue, loc)
+	}
+	for i := 0; i < len(fe.queue); i++ {
+		current := fe.queue[i]
+		if fe.dist[current] >= fe.maxDist {
+			continue
+		}
+		for dir := North; dir <= West; dir++ {
+			next := fe.m.Move(current, dir)
+			if fe.m.Water.Has(next) || fe.reached[next] == fe.search {
+				continue
+			}
+			fe.reached[next] = fe.search
+			fe.dist[next] = fe.dist[current] + 1
+			fe.origin[next] = fe.origin[current]
+			fe.queue = append(fe.queue, next)
+		}
+	}
+}
+
+//Update values all the food on the map.
+func (fe *FoodEconomy) Update() {
+	fe.Foods = fe.Foods[:0]
+	fe.byLoc = make(map[Location]*FoodValue)
+	for _, loc := range fe.m.Food.Locations() {
+		fv := &FoodValue{Loc: loc, Distance: -1, EnemyDistance: -1}
+		fe.Foods = append(fe.Foods, fv)
+		fe.byLoc[loc] = fv
+	}
+	if len(fe.Foods) == 0 {
+		return
+	}
+
+	mine, enemies := []Location{}, []Location{}
+	for _, loc := range fe.m.Ants.Locations() {
+		if fe.m.Ants.At(loc).ToAnt() == MY_ANT {
+			mine = append(mine, loc)
+		} else {
+			enemies = append(enemies, loc)
+		}
+	}
+
+	fe.flood(enemies)
+	for _, fv := range fe.Foods {
+		if fe.reached[fv.Loc] == fe.search {
+			fv.EnemyDistance = fe.dist[fv.Loc]
+		}
+	}
+	fe.flood(mine)
+	for _, fv := range fe.Foods {
+		if fe.reached[fv.Loc] == fe.search {
+			fv.Distance = fe.dist[fv.Loc]
+			fv.Gatherer = fe.origin[fv.Loc]
+		}
+	}
+	for _, fv := range fe.Foods {
+		if fv.Distance < 0 {
+			continue
+		}
+		fv.Contest = fe.contest(fv)
+		switch fv.Contest {
+		case CONTEST_AMBUSH, CONTEST_ABANDON:
+			fv.Value = 0
+		case CONTEST_RACE:
+			fv.Value = math.Pow(fe.discount, float64(fv.Distance)) * fe.contested
+		default:
+			fv.Value = math.Pow(fe.discount, float64(fv.Distance))
+		}
+	}
+	for _, fv := range fe.Foods {
+		if fv.Contest == CONTEST_AMBUSH {
+			fv.Ambush = fe.ambushSpot(fv)
+		}
+	}
+	sort.Sort(byValue(fe.Foods))
+}
+
+//Nearest returns the nearest square to the food at loc, within the steps the
+//economy looks, that free says has an ant we can send, and how many steps
+//away it is, -1 if there's none. It overwrites the scratch space of the
+//searches.
+func (fe *FoodEconomy) Nearest(loc Location, free func(loc Location) bool) (Location, int) {
+	fe.flood([]Location{loc})
+	for _, near := range fe.queue {
+		if free(near) {
+			return near, fe.dist[near]
+		}
+	}
+	return loc, -1
+}
+
+//Food returns the value of the food at loc, or nil if there's none.
+func (fe *FoodEconomy) Food(loc Location) *FoodValue {
+	return fe.byLoc[loc]
+}
+
+//Value returns what the food at loc is worth, 0 if there's none.
+func (fe *FoodEconomy) Value(loc Location) float64 {
+	if fv, exists := fe.byLoc[loc]; exists {
+		return fv.Value
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFoodEconomy(t *testing.T) {
+	m := NewMap(20, 20)
+	fe := NewFoodEconomy(m, 5, 10, 20, 0.9, 0.5)
+
+	free := m.FromRowCol(2, 4)
+	m.AddFood(free)
+	m.AddAnt(m.FromRowCol(2, 2), MY_ANT)
+
+	tied := m.FromRowCol(10, 10)
+	m.AddFood(tied)
+	m.AddAnt(m.FromRowCol(10, 7), MY_ANT)
+	m.AddAnt(m.FromRowCol(10, 13), ANT_1)
+
+	lost := m.FromRowCol(16, 4)
+	m.AddFood(lost)
+	m.AddAnt(m.FromRowCol(16, 8), MY_ANT)
+	m.AddAnt(m.FromRowCol(16, 3), ANT_2)
+
+	far := m.FromRowCol(6, 14)
+	m.AddFood(far)
+
+	fe.Update()
+
+	fv := fe.byLoc[free]
+	if fv.Distance != 2 || fv.Gatherer != m.FromRowCol(2, 2) || fv.Contested() {
+		t.Errorf("free food: distance %d, gatherer %v, contested %v", fv.Distance, fv.Gatherer, fv.Contested())
+	}
+	if !fe.byLoc[tied].Contested() || fe.Value(tied) >= 0.9*0.9*0.9 {
+		t.Errorf("food an enemy can tie for is worth %f", fe.Value(tied))
+	}
+	if fe.Value(lost) != 0 {
+		t.Errorf("food an enemy gets to first is worth %f", fe.Value(lost))
+	}
+	if fe.Value(far) != 0 || fe.byLoc[far].Distance != -1 {
+		t.Errorf("food out of reach is worth %f", fe.Value(far))
+	}
+	if fe.Foods[0].Loc != free {
+		t.Errorf("the free food isn't the most valuable")
+	}
+	if fe.Food(tied).Contest != CONTEST_RACE || fe.Food(lost).Contest != CONTEST_ABANDON {
+		t.Errorf("tied food is a %s, lost food a %s", fe.Food(tied).Contest, fe.Food(lost).Contest)
+	}
+
+	//an enemy gets there first, but we have the numbers to catch it
+	m = NewMap(20, 20)
+	fe = NewFoodEconomy(m, 5, 10, 50, 0.9, 0.5)
+	food := m.FromRowCol(5, 10)
+	m.AddFood(food)
+	m.AddAnt(m.FromRowCol(5, 12), ANT_1)
+	m.AddAnt(m.FromRowCol(5, 5), MY_ANT)
+	m.AddAnt(m.FromRowCol(6, 5), MY_ANT)
+	fe.Update()
+	fv = fe.Food(food)
+	if fv.Contest != CONTEST_AMBUSH || fv.Value != 0 {
+		t.Fatalf("outnumbered enemy's food is a %s worth %f", fv.Contest, fv.Value)
+	}
+	if fv.Ambush != m.FromRowCol(5, 7) {
+		row, col := m.FromLocation(fv.Ambush)
+		t.Errorf("ambush at (%d, %d), want (5, 7)", row, col)
+	}
+
+	//the nearest ant that's free goes, not the nearest one
+	busy, idle := m.FromRowCol(5, 5), m.FromRowCol(6, 5)
+	if loc, dist := fe.Nearest(food, func(loc Location) bool { return loc == idle }); loc != idle || dist != 6 {
+		t.Errorf("nearest free ant %v is %d away", loc, dist)
+	}
+	if loc, dist := fe.Nearest(food, func(loc Location) bool { return loc == busy }); loc != busy || dist != 5 {
+		t.Errorf("nearest free ant %v is %d away", loc, dist)
+	}
+	if _, dist := fe.Nearest(far, func(loc Location) bool { return false }); dist != -1 {
+		t.Errorf("found a free ant when there's none")
+	}
+
+	//an enemy close by but behind water can't join the fight in time
+	m = NewMap(20, 20)
+	fe = NewFoodEconomy(m, 5, 10, 25, 0.9, 0.5)
+	for col := 3; col < 18; col++ {
+		m.AddWater(m.FromRowCol(6, col))
+	}
+	walled := m.FromRowCol(5, 10)
+	m.AddFood(walled)
+	m.AddAnt(m.FromRowCol(5, 12), ANT_1)
+	m.AddAnt(m.FromRowCol(7, 10), ANT_1)
+	m.AddAnt(m.FromRowCol(5, 7), MY_ANT)
+	m.AddAnt(m.FromRowCol(4, 7), MY_ANT)
+	fe.Update()
+	if c := fe.Food(walled).Contest; c != CONTEST_AMBUSH {
+		t.Errorf("food with an enemy behind water is a %s, want ambush", c)
+	}
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bufio"
+	"exec"
+	"fmt"
+	"io"
+	"os"
+	"rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Engine plays games by the contest rules (move, attack, raze, spawn, gather,
+//spawn food) between bots running as subprocesses, talking to them with the
+//same protocol the real server uses.
+
+//Each player gets this chance of a new food item appearing every turn.
+const ENGINE_FOOD_RATE = 0.5
+
+//GameMap is a map loaded from a .map file.
+type GameMap struct {
+	Rows    int
+	Cols    int
+	Players int
+
+	water []bool
+	food  []Location
+	ants  map[Location]int //owner of each starting ant
+	hills map[Location]int //owner of each hill
+}
+
+//LoadGameMap reads a map in the contest's .map format.
+func LoadGameMap(fname string) (*GameMap, os.Error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gm := &GameMap{
+		ants:  make(map[Location]int),
+		hills: make(map[Location]int),
+	}
+	rows := []string{}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		words := strings.SplitN(line, " ", 2)
+		if len(words) == 2 {
+			switch words[0] {
+			case "rows":
+				gm.Rows, _ = strconv.Atoi(words[1])
+			case "cols":
+				gm.Cols, _ = strconv.Atoi(words[1])
+			case "players":
+				gm.Players, _ = strconv.Atoi(words[1])
+			case "m":
+				rows = append(rows, words[1])
+			}
+		}
+		if err == os.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if gm.Rows == 0 || gm.Cols == 0 || len(rows) != gm.Rows {
+		return nil, os.NewError(fname + ": bad map dimensions")
+	}
+
+	gm.water = make([]bool, gm.Rows*gm.Cols)
+	for row, line := range rows {
+		if len(line) != gm.Cols {
+			return nil, fmt.Errorf("%s: row %d has %d columns, expected %d", fname, row, len(line), gm.Cols)
+		}
+		for col := 0; col < gm.Cols; col++ {
+			loc := Location(row*gm.Cols + col)
+			ch := line[col]
+			switch {
+			case ch == '%':
+				gm.water[loc] = true
+			case ch == '*':
+				gm.food = append(gm.food, loc)
+			case ch >= 'a' && ch <= 'j':
+				gm.ants[loc] = int(ch - 'a')
+			case ch >= '0' && ch <= '9':
+				gm.hills[loc] = int(ch - '0')
+			case ch >= 'A' && ch <= 'J':
+				gm.ants[loc] = int(ch - 'A')
+				gm.hills[loc] = int(ch - 'A')
+			}
+		}
+	}
+	return gm, nil
+}
+
+//gamePlayer is one bot subprocess.
+type gamePlayer struct {
+	name  string
+	cmd   *exec.Cmd
+	in    io.WriteCloser
+	lines chan string
+
+	alive     bool //still answering in time
+	seenWater []bool
+}
+
+func startPlayer(name string, command []string) (*gamePlayer, os.Error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &gamePlayer{name: name, cmd: cmd, in: in, lines: make(chan string, 100), alive: true}
+	go func() {
+		r := bufio.NewReader(out)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				close(p.lines)
+				return
+			}
+			p.lines <- strings.TrimRight(line, "\r\n")
+		}
+	}()
+	return p, nil
+}
+
+//waitForGo collects the lines the bot sends up to the next "go", giving up
+//on the bot if that takes longer than timeout milliseconds.
+func (p *gamePlayer) waitForGo(timeout int) []string {
+	lines := []string{}
+	deadline := time.After(int64(timeout) * 1000000)
+	for p.alive {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				p.alive = false
+			} else if line == "go" {
+				return lines
+			} else {
+				lines = append(lines, line)
+			}
+		case <-deadline:
+			p.alive = false
+		}
+	}
+	return nil
+}
+
+func (p *gamePlayer) stop() {
+	p.in.Close()
+	if !p.alive {
+		p.cmd.Process.Kill()
+	}
+	//give the bot a second to exit by itself, the output has to be drained
+	//before Wait
+	deadline := time.After(1000000000)
+	for {
+		select {
+		case _, ok := <-p.lines:
+			if !ok {
+				p.cmd.Wait()
+				return
+			}
+		case <-deadline:
+			p.cmd.Process.Kill()
+			deadline = nil
+		}
+	}
+}
+
+type gameHill struct {
+	owner int
+	alive bool
+}
+
+type gameAnt struct {
+	owner int
+	dest  Location
+}
+
+//Game is a single game in progress.
+type Game struct {
+	Turns         int
+	LoadTime      int
+	TurnTime      int
+	ViewRadius2   int
+	AttackRadius2 int
+	SpawnRadius2  int
+	Seed          int64
+
+	gm      *GameMap
+	m       *Map //only used for coordinates
+	rand    *rand.Rand
+	turn    int
+	players []*gamePlayer
+
+	food  map[Location]bool
+	ants  map[Location]*gameAnt
+	hills map[Location]*gameHill
+	dead  map[Location]int
+	hive  []int
+	Score []int
+}
+
+//NewGame sets up a game on gm with the usual contest parameters.
+func NewGame(gm *GameMap, seed int64) *Game {
+	g := &Game{
+		Turns:         1000,
+		LoadTime:      3000,
+		TurnTime:      500,
+		ViewRadius2:   77,
+		AttackRadius2: 5,
+		SpawnRadius2:  1,
+		Seed:          seed,
+		gm:            gm,
+		m:             NewMap(gm.Rows, gm.Cols),
+		rand:          rand.New(rand.NewSource(seed)),
+		food:          make(map[Location]bool),
+		ants:          make(map[Location]*gameAnt),
+		hills:         make(map[Location]*gameHill),
+		hive:          make([]int, gm.Players),
+		Score:         make([]int, gm.Players),
+	}
+	for _, loc := range gm.food {
+		g.food[loc] = true
+	}
+	for loc, owner := range gm.ants {
+		g.ants[loc] = &gameAnt{owner: owner, dest: loc}
+	}
+	for loc, owner := range gm.hills {
+		g.hills[loc] = &gameHill{owner: owner, alive: true}
+		g.Score[owner]++
+		g.hive[owner]++
+	}
+	g.doSpawn() //everybody starts with an ant on each hill
+	return g
+}
+
+//dist2 is the squared distance between two locations, wrapping around the edges.
+func (g *Game) dist2(a, b Location) int {
+	arow, acol := g.m.FromLocation(a)
+	brow, bcol := g.m.FromLocation(b)
+	dr := wrapDelta(arow, brow, g.m.Rows)
+	dc := wrapDelta(acol, bcol, g.m.Cols)
+	return dr*dr + dc*dc
+}
+
+//Play runs the game to the end, with the bot for each seat started from
+//commands. It returns the rank of each seat (0 is best, ties share a rank).
+func (g *Game) Play(names []string, commands [][]string) ([]int, os.Error) {
+	if len(commands) != g.gm.Players {
+		return nil, fmt.Errorf("map needs %d players, got %d", g.gm.Players, len(commands))
+	}
+	for i, command := range commands {
+		p, err := startPlayer(names[i], command)
+		if err != nil {
+			g.stopPlayers()
+			return nil, err
+		}
+		p.seenWater = make([]bool, g.m.Rows*g.m.Cols)
+		g.players = append(g.players, p)
+	}
+	defer g.stopPlayers()
+
+	for i, p := range g.players {
+		//every seat gets its own seed, so two copies of a bot don't play alike
+		fmt.Fprintf(p.in, "turn 0\nloadtime %d\nturntime %d\nrows %d\ncols %d\nturns %d\n",
+			g.LoadTime, g.TurnTime, g.m.Rows, g.m.Cols, g.Turns)
+		fmt.Fprintf(p.in, "viewradius2 %d\nattackradius2 %d\nspawnradius2 %d\nplayer_seed %d\nready\n",
+			g.ViewRadius2, g.AttackRadius2, g.SpawnRadius2, g.Seed*int64(len(g.players))+int64(i))
+	}
+	for _, p := range g.players {
+		p.waitForGo(g.LoadTime)
+	}
+
+	for g.turn = 1; g.turn <= g.Turns && g.playersLeft() > 1; g.turn++ {
+		for i, p := range g.players {
+			if p.alive {
+				g.sendState(i)
+			}
+		}
+		for i, p := range g.players {
+			if p.alive {
+				g.doOrders(i, p.waitForGo(g.TurnTime*3/2))
+			}
+		}
+		g.dead = make(map[Location]int)
+		g.doMoves()
+		g.doAttack()
+		g.doRaze()
+		g.doSpawn()
+		g.doGather()
+		g.doFood()
+	}
+
+	for _, p := range g.players {
+		if p.alive {
+			fmt.Fprintf(p.in, "end\nplayers %d\nscore", len(g.players))
+			for _, score := range g.Score {
+				fmt.Fprintf(p.in, " %d", score)
+			}
+			fmt.Fprintf(p.in, "\ngo\n")
+		}
+	}
+	return g.ranks(), nil
+}
+
+func (g *Game) stopPlayers() {
+	for _, p := range g.players {
+		p.stop()
+	}
+}
+
+//playersLeft counts the players who still have ants or hills.
+func (g *Game) playersLeft() int {
+	left := make([]bool, len(g.Score))
+	for _, ant := range g.ants {
+		left[ant.owner] = true
+	}
+	for _, hill := range g.hills {
+		if hill.alive {
+			left[hill.owner] = true
+		}
+	}
+	count := 0
+	for _, l := range left {
+		if l {
+			count++
+		}
+	}
+	return count
+}
+
+func (g *Game) ranks() []int {
+	ranks := make([]int, len(g.Score))
+	for i := range g.Score {
+		for j := range g.Score {
+			if g.Score[j] > g.Score[i] {
+				ranks[i]++
+			}
+		}
+	}
+	return ranks
+}
+
+//sendState tells player what it can see, with owners renumbered so that it
+//is always player 0.
+func (g *Game) sendState(player int) {
+	p := g.players[player]
+	n := len(g.players)
+	relative := func(owner int) int {
+		return (owner - player + n) % n
+	}
+
+	visible := make([]bool, g.m.Rows*g.m.Cols)
+	for loc, ant := range g.ants {
+		if ant.owner == player {
+			g.m.DoInRad(loc, g.ViewRadius2, func(row, col int) {
+				visible[g.m.FromRowCol(row, col)] = true
+			})
+		}
+	}
+
+	w := bufio.NewWriter(p.in)
+	fmt.Fprintf(w, "turn %d\n", g.turn)
+	for i, seen := range visible {
+		if !seen {
+			continue
+		}
+		loc := Location(i)
+		row, col := g.m.FromLocation(loc)
+		if g.gm.water[loc] && !p.seenWater[loc] {
+			p.seenWater[loc] = true
+			fmt.Fprintf(w, "w %d %d\n", row, col)
+		}
+		if g.food[loc] {
+			fmt.Fprintf(w, "f %d %d\n", row, col)
+		}
+		if hill, exists := g.hills[loc]; exists && hill.alive {
+			fmt.Fprintf(w, "h %d %d %d\n", row, col, relative(hill.owner))
+		}
+		if ant, exists := g.ants[loc]; exists {
+			fmt.Fprintf(w, "a %d %d %d\n", row, col, relative(ant.owner))
+		}
+		if owner, exists := g.dead[loc]; exists {
+			fmt.Fprintf(w, "d %d %d %d\n", row, col, relative(owner))
+		}
+	}
+	fmt.Fprintf(w, "go\n")
+	w.Flush()
+}
+
+//doOrders records where each of player's ants wants to go. Orders for ants
+//that aren't there, second orders and moves into water are ignored.
+func (g *Game) doOrders(player int, orders []string) {
+	ordered := make(map[Location]bool)
+	for _, order := range orders {
+		words := strings.Fields(order)
+		if len(words) != 4 || words[0] != "o" {
+			continue
+		}
+		row, err1 := strconv.Atoi(words[1])
+		col, err2 := strconv.Atoi(words[2])
+		if err1 != nil || err2 != nil || row < 0 || row >= g.m.Rows || col < 0 || col >= g.m.Cols {
+			continue
+		}
+		loc := g.m.FromRowCol(row, col)
+		ant, exists := g.ants[loc]
+		if !exists || ant.owner != player || ordered[loc] {
+			continue
+		}
+		dir, err := ParseDirection(words[3])
+		if err != nil || dir == NoMovement {
+			continue
+		}
+		dest := g.m.Move(loc, dir)
+		if g.gm.water[dest] {
+			continue
+		}
+		ordered[loc] = true
+		ant.dest = dest
+	}
+}
+
+//doMoves moves every ant to its destination, killing all the ants that end
+//up on the same square.
+func (g *Game) doMoves() {
+	moved := make(map[Location][]*gameAnt)
+	for _, ant := range g.ants {
+		moved[ant.dest] = append(moved[ant.dest], ant)
+	}
+	g.ants = make(map[Location]*gameAnt)
+	for loc, ants := range moved {
+		if len(ants) > 1 {
+			for _, ant := range ants {
+				g.dead[loc] = ant.owner
+			}
+			continue
+		}
+		ants[0].dest = loc //stays put unless ordered next turn
+		g.ants[loc] = ants[0]
+	}
+}
+
+//doAttack applies the focus rule: an ant dies if any enemy in range of it is
+//in range of no more enemies than it is.
+func (g *Game) doAttack() {
+	enemies := make(map[Location][]Location)
+	for loc, ant := range g.ants {
+		for other, otherAnt := range g.ants {
+			if otherAnt.owner != ant.owner && g.dist2(loc, other) <= g.AttackRadius2 {
+				enemies[loc] = append(enemies[loc], other)
+			}
+		}
+	}
+	for loc, ant := range g.ants {
+		for _, enemy := range enemies[loc] {
+			if len(enemies[enemy]) <= len(enemies[loc]) {
+				g.dead[loc] = ant.owner
+				break
+			}
+		}
+	}
+	for loc := range g.dead {
+		g.ants[loc] = nil, false
+	}
+}
+
+func (g *Game) doRaze() {
+	for loc, hill := range g.hills {
+		ant, exists := g.ants[loc]
+		if hill.alive && exists && ant.owner != hill.owner {
+			hill.alive = false
+			g.Score[ant.owner] += 2
+			g.Score[hill.owner]--
+		}
+	}
+}
+
+type locations []Location
+
+func (l locations) Len() int           { return len(l) }
+func (l locations) Less(i, j int) bool { return l[i] < l[j] }
+func (l locations) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+//doSpawn puts a new ant on each free hill of a player with food in the hive.
+func (g *Game) doSpawn() {
+	hills := locations{}
+	for loc := range g.hills {
+		hills = append(hills, loc)
+	}
+	sort.Sort(hills)
+	for _, loc := range hills {
+		hill := g.hills[loc]
+		if _, occupied := g.ants[loc]; hill.alive && !occupied && g.hive[hill.owner] > 0 {
+			g.hive[hill.owner]--
+			g.ants[loc] = &gameAnt{owner: hill.owner, dest: loc}
+		}
+	}
+}
+
+//doGather gives food to the only player with an ant next to it, food next
+//to ants of more than one player is destroyed.
+func (g *Game) doGather() {
+	for food := range g.food {
+		owner := -1
+		contested := false
+		for loc, ant := range g.ants {
+			if g.dist2(food, loc) <= g.SpawnRadius2 {
+				if owner != -1 && owner != ant.owner {
+					contested = true
+				}
+				owner = ant.owner
+			}
+		}
+		if owner == -1 {
+			continue
+		}
+		g.food[food] = false, false
+		if !contested {
+			g.hive[owner]++
+		}
+	}
+}
+
+func (g *Game) doFood() {
+	for i := 0; i < len(g.players); i++ {
+		if g.rand.Float64() >= ENGINE_FOOD_RATE {
+			continue
+		}
+		loc := Location(g.rand.Intn(g.m.Rows * g.m.Cols))
+		_, antExists := g.ants[loc]
+		_, hillExists := g.hills[loc]
+		if !g.gm.water[loc] && !g.food[loc] && !antExists && !hillExists {
+			g.food[loc] = true
+		}
+	}
+}
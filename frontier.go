@@ -0,0 +1,226 @@
+package main
+
+import (
+	"sort"
+)
+
+//FrontierCluster is a connected group of frontier squares.
+type FrontierCluster struct {
+	Squares  []Location
+	Target   Location //the square closest to one of our ants
+	Distance int      //steps from our nearest ant to Target
+}
+
+type clustersBySize []*FrontierCluster
+
+func (c clustersBySize) Len() int           { return len(c) }
+func (c clustersBySize) Less(i, j int) bool { return len(c[i].Squares) > len(c[j].Squares) }
+func (c clustersBySize) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+//FrontierMap finds the edge of what we've explored: land we can reach that
+//borders squares we have never seen, or haven't seen in staleTurns turns.
+type FrontierMap struct {
+	m *Map
+
+	lastSeen []int //turn each square was last visible, -1 if never
+	turn     int
+
+	dist      []int //steps from our nearest ant over known land, -1 if unreachable
+	clusterOf []int //index into Clusters, -1 if not a frontier square
+	queue     []Location
+
+	Clusters []*FrontierCluster
+
+	staleTurns  int //a square not seen for this long is as good as unexplored
+	maxClusters int //only the largest clusters are handed out to explorers
+}
+
+//NewFrontierMap returns a frontier map for m where nothing has been seen.
+func NewFrontierMap(m *Map, staleTurns, maxClusters int) *FrontierMap {
+	f := &FrontierMap{
+		m:           m,
+		lastSeen:    make([]int, m.Rows*m.Cols),
+		dist:        make([]int, m.Rows*m.Cols),
+		clusterOf:   make([]int, m.Rows*m.Cols),
+		staleTurns:  staleTurns,
+		maxClusters: maxClusters,
+	}
+	for i := range f.lastSeen {
+		f.lastSeen[i] = -1
+	}
+	return f
+}
+
+//fresh returns true if loc has been seen within the last staleTurns turns.
+func (f *FrontierMap) fresh(loc Location) bool {
+	return f.lastSeen[loc] >= 0 && f.turn-f.lastSeen[loc] <= f.staleTurns
+}
+
+//passable returns true if loc has been seen and isn't water.
+func (f *FrontierMap) passable(loc Location) bool {
+	return f.lastSeen[loc] >= 0 && !f.m.Water.Has(loc)
+}
+
+//isFrontier returns true if loc is fresh land next to an unseen or stale square.
+func (f *FrontierMap) isFrontier(loc Location) bool {
+	if !f.fresh(loc) || f.m.Water.Has(loc) {
+		return false
+	}
+	for dir := Direction(0); dir < 4; dir++ {
+		next := f.m.Move(loc, dir)
+		if !f.fresh(next) && !f.m.Water.Has(next) {
+			return true
+		}
+	}
+	return false
+}
+
+//bfs fills dist with the number of steps from the nearest of sources over
+//known land, stopping early once every location in stop has been reached.
+func (f *FrontierMap) bfs(sources []Location, stop map[Location]bool) {
+	for i := range f.dist {
+		f.dist[i] = -1
+	}
+	f.queue = f.queue[:0]
+	for _, loc := range sources {
+		if f.dist[loc] == -1 {
+			f.dist[loc] = 0
+			f.queue = append(f.queue, loc)
+		}
+	}
+	remaining := len(stop)
+	for i := 0; i < len(f.queue); i++ {
+		loc := f.queue[i]
+		if stop[loc] {
+			remaining--
+			if remaining == 0 {
+				return
+			}
+		}
+		for dir := Direction(0); dir < 4; dir++ {
+			next := f.m.Move(loc, dir)
+			if f.dist[next] == -1 && f.passable(next) {
+				f.dist[next] = f.dist[loc] + 1
+				f.queue = append(f.queue, next)
+			}
+		}
+	}
+}
+
+//Update records what is visible this turn, then finds and clusters the
+//frontier squares that can be reached from ants.
+func (f *FrontierMap) Update(m *Map, turn int, ants []Location) {
+	f.turn = turn
+	for i, item := range m.itemGrid {
+		if item != UNKNOWN && item != WATER {
+			f.lastSeen[i] = turn
+		}
+	}
+
+	f.bfs(ants, nil)
+	reached := make([]Location, len(f.queue))
+	copy(reached, f.queue)
+
+	for i := range f.clusterOf {
+		f.clusterOf[i] = -1
+	}
+	clusters := []*FrontierCluster{}
+	for _, loc := range reached {
+		if f.clusterOf[loc] != -1 || !f.isFrontier(loc) {
+			continue
+		}
+
+		//flood fill the cluster, diagonals count as connected
+		c := &FrontierCluster{Target: loc, Distance: f.dist[loc]}
+		f.clusterOf[loc] = len(clusters)
+		stack := []Location{loc}
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			c.Squares = append(c.Squares, cur)
+			if f.dist[cur] < c.Distance {
+				c.Distance = f.dist[cur]
+				c.Target = cur
+			}
+			row, col := m.FromLocation(cur)
+			for dr := -1; dr <= 1; dr++ {
+				for dc := -1; dc <= 1; dc++ {
+					next := m.FromRowCol(row+dr, col+dc)
+					if f.clusterOf[next] == -1 && f.dist[next] != -1 && f.isFrontier(next) {
+						f.clusterOf[next] = len(clusters)
+						stack = append(stack, next)
+					}
+				}
+			}
+		}
+		clusters = append(clusters, c)
+	}
+
+	sort.Sort(clustersBySize(clusters))
+	if len(clusters) > f.maxClusters {
+		clusters = clusters[:f.maxClusters]
+	}
+	f.Clusters = clusters
+}
+
+type frontierPair struct {
+	dist    int
+	ant     Location
+	cluster int
+}
+
+type pairsByDist []frontierPair
+
+func (p pairsByDist) Len() int           { return len(p) }
+func (p pairsByDist) Less(i, j int) bool { return p[i].dist < p[j].dist }
+func (p pairsByDist) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+//Assign hands each explorer a distinct frontier cluster, closest pairs
+//first, and returns the square each explorer should head for. Clusters
+//containing a location in taken are already being explored and are skipped.
+//Explorers left over when the clusters run out aren't in the result.
+func (f *FrontierMap) Assign(explorers []Location, taken []Location) map[Location]Location {
+	targets := make(map[Location]Location)
+	if len(explorers) == 0 {
+		return targets
+	}
+
+	used := make([]bool, len(f.Clusters))
+	for _, loc := range taken {
+		for i, c := range f.Clusters {
+			if containsLocation(c.Squares, loc) {
+				used[i] = true
+			}
+		}
+	}
+
+	stop := make(map[Location]bool)
+	for _, loc := range explorers {
+		stop[loc] = true
+	}
+	pairs := []frontierPair{}
+	for i, c := range f.Clusters {
+		if used[i] {
+			continue
+		}
+		f.bfs(c.Squares, stop)
+		for _, loc := range explorers {
+			if f.dist[loc] != -1 {
+				pairs = append(pairs, frontierPair{f.dist[loc], loc, i})
+			}
+		}
+	}
+
+	sort.Sort(pairsByDist(pairs))
+	for _, p := range pairs {
+		if used[p.cluster] {
+			continue
+		}
+		if _, assigned := targets[p.ant]; assigned {
+			continue
+		}
+		used[p.cluster] = true
+		targets[p.ant] = f.Clusters[p.cluster].Target
+	}
+	return targets
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFrontierMap(t *testing.T) {
+	m := NewMap(20, 20)
+	f := NewFrontierMap(m, 5, 10)
+	center := m.FromRowCol(10, 10)
+
+	//the edge of what one ant sees is a single cluster
+	m.AddLand(center, 17)
+	f.Update(m, 0, []Location{center})
+	if len(f.Clusters) != 1 || len(f.Clusters[0].Squares) < 8 {
+		t.Fatalf("%d clusters after one look around", len(f.Clusters))
+	}
+	outer := len(f.Clusters[0].Squares)
+
+	//squares seen a few turns ago are still explored
+	m.Reset()
+	m.AddLand(center, 5)
+	f.Update(m, 3, []Location{center})
+	if len(f.Clusters) != 1 || len(f.Clusters[0].Squares) != outer {
+		t.Errorf("frontier moved before the outer squares went stale")
+	}
+
+	//but once they're older than staleTurns the frontier closes in
+	m.Reset()
+	m.AddLand(center, 5)
+	f.Update(m, 10, []Location{center})
+	if len(f.Clusters) != 1 {
+		t.Fatalf("%d clusters once the outer squares went stale", len(f.Clusters))
+	}
+	for _, loc := range f.Clusters[0].Squares {
+		if m.Distance2(center, loc) >= 5 {
+			t.Errorf("stale square %d still on the frontier", loc)
+		}
+	}
+}
+
+func TestFrontierAssign(t *testing.T) {
+	m := NewMap(20, 20)
+	f := NewFrontierMap(m, 5, 10)
+	left, right := m.FromRowCol(5, 5), m.FromRowCol(5, 15)
+	m.AddLand(left, 10)
+	m.AddLand(right, 10)
+	a, b, c := m.FromRowCol(5, 4), m.FromRowCol(5, 6), m.FromRowCol(5, 15)
+	f.Update(m, 0, []Location{a, b, c})
+	if len(f.Clusters) != 2 {
+		t.Fatalf("%d clusters for two patches of land", len(f.Clusters))
+	}
+
+	//each cluster goes to one explorer, and they can only get to their own
+	targets := f.Assign([]Location{a, b, c}, nil)
+	if len(targets) != 2 {
+		t.Fatalf("%d explorers given a target for two clusters", len(targets))
+	}
+	_, hasA := targets[a]
+	_, hasB := targets[b]
+	if hasA == hasB {
+		t.Errorf("both or neither of the ants on the left got a target")
+	}
+	if target, ok := targets[c]; !ok || m.Distance2(right, target) >= 10 {
+		t.Errorf("ant on the right sent to %d", target)
+	}
+	for ant, target := range targets {
+		if ant != c && m.Distance2(left, target) >= 10 {
+			t.Errorf("ant on the left sent to %d", target)
+		}
+	}
+
+	//a cluster somebody is already exploring isn't handed out again
+	targets = f.Assign([]Location{a, b, c}, []Location{f.Clusters[0].Target})
+	if len(targets) != 1 {
+		t.Errorf("%d explorers given a target with one cluster left", len(targets))
+	}
+}
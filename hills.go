@@ -0,0 +1,167 @@
+package main
+
+//DefendBehavior calls in the ants nearest our hills when enemies come close
+//to them, one more than there are enemies, and has them stand between the
+//hill and the enemy nearest it, at a chokepoint if there's one on the way.
+//Defenders always outbid the raids, which bid at most 1 before weighting.
+//The fighting itself is left to the skirmish and attack behaviors once the
+//enemy comes in range.
+type DefendBehavior struct {
+	targets map[*Ant]Location //where each defender stands guard
+}
+
+func (b *DefendBehavior) Name() string {
+	return "defend"
+}
+
+func (b *DefendBehavior) Prepare(me *GarboAnt) {
+	s := me.state
+	b.targets = make(map[*Ant]Location)
+
+	for _, hill := range s.Map.Hills.Locations() {
+		if s.Map.Hills.At(hill) != MY_HILL {
+			continue
+		}
+		enemies := 0
+		threat, threatDist := Location(0), 0
+		for _, loc := range s.Map.Ants.Locations() {
+			if s.Map.Ants.At(loc).ToAnt() == MY_ANT {
+				continue
+			}
+			if d := s.Map.Distance2(hill, loc); d <= me.config.DefendRadius2 {
+				if enemies == 0 || d < threatDist {
+					threat, threatDist = loc, d
+				}
+				enemies++
+			}
+		}
+		if enemies == 0 {
+			continue
+		}
+		guard := centroid(s.Map, []Location{hill, threat})
+		for s.Map.Water.Has(guard) && guard != hill {
+			guard = centroid(s.Map, []Location{hill, guard})
+		}
+
+		// A chokepoint between the hill and the enemy is easier to hold
+		middle, bestDist := guard, -1
+		for _, choke := range me.terrain.Chokepoints {
+			if s.Map.Distance2(choke, hill) >= threatDist || s.Map.Distance2(choke, threat) >= threatDist {
+				continue
+			}
+			if d := s.Map.Distance2(choke, middle); bestDist < 0 || d < bestDist {
+				guard, bestDist = choke, d
+			}
+		}
+
+		// The nearest ants that aren't defending already go
+		for need := enemies + 1; need > 0; need-- {
+			var best *Ant
+			bestDist := 0
+			for loc, ant := range me.ants {
+				if _, taken := b.targets[ant]; taken {
+					continue
+				}
+				if d := s.Map.Distance2(hill, loc); best == nil || d < bestDist {
+					best, bestDist = ant, d
+				}
+			}
+			if best == nil {
+				break
+			}
+			b.targets[best] = guard
+		}
+		row, col := s.Map.FromLocation(hill)
+		s.Log.Debugf("%d enemies near our hill at %d %d", enemies, row, col)
+	}
+}
+
+func (b *DefendBehavior) Bid(me *GarboAnt, ant *Ant) float64 {
+	if _, defending := b.targets[ant]; !defending {
+		return 0
+	}
+	// No raid is worth losing the hill over, however the weights are set
+	bid := 1.5
+	raid, defend := me.strategy.Weight("raid"), me.strategy.Weight("defend")
+	if defend > 0 && raid/defend > bid {
+		bid = raid/defend + 0.1
+	}
+	return bid
+}
+
+func (b *DefendBehavior) Move(me *GarboAnt, ant *Ant) {
+	if guard := b.targets[ant]; ant.loc != guard {
+		me.nextBFSMove(ant, guard, true)
+	}
+}
+
+//RaidBehavior goes for the enemy hills we know of or expect from the map's
+//symmetry. Every ant bids for the hill nearest it, more the nearer it is and
+//the surer we are of the hill, so how many ants go is down to the raid
+//weight the game phase sets against the other behaviors. Hills with more
+//defenders than we can match nearby are left alone.
+type RaidBehavior struct {
+	targets map[*Ant]Location
+	bids    map[*Ant]float64
+}
+
+func (b *RaidBehavior) Name() string {
+	return "raid"
+}
+
+func (b *RaidBehavior) Prepare(me *GarboAnt) {
+	s := me.state
+	b.targets = make(map[*Ant]Location)
+	b.bids = make(map[*Ant]float64)
+
+	hills := make(map[Location]float64)
+	for loc := range me.knownHills {
+		hills[loc] = 1
+	}
+	for loc, confidence := range me.predictedHills {
+		if _, known := hills[loc]; !known {
+			hills[loc] = float64(confidence)
+		}
+	}
+	if len(hills) == 0 {
+		return
+	}
+
+	for hill := range hills {
+		defenders, raiders := 0, 0
+		for _, loc := range s.Map.Ants.Locations() {
+			if s.Map.Ants.At(loc).ToAnt() != MY_ANT && s.Map.Distance2(hill, loc) <= me.config.DefendRadius2 {
+				defenders++
+			}
+		}
+		for loc := range me.ants {
+			if s.Map.Distance2(hill, loc) <= me.config.RaidRadius2 {
+				raiders++
+			}
+		}
+		if float64(raiders) < me.config.AttackRatio*float64(defenders) {
+			hills[hill] = 0, false
+		}
+	}
+
+	radius2 := float64(me.config.RaidRadius2)
+	for loc, ant := range me.ants {
+		for hill, confidence := range hills {
+			d := float64(s.Map.Distance2(loc, hill))
+			if bid := confidence * radius2 / (radius2 + d); bid > b.bids[ant] {
+				b.targets[ant] = hill
+				b.bids[ant] = bid
+			}
+		}
+	}
+}
+
+func (b *RaidBehavior) Bid(me *GarboAnt, ant *Ant) float64 {
+	return b.bids[ant]
+}
+
+func (b *RaidBehavior) Move(me *GarboAnt, ant *Ant) {
+	if !me.nextBFSMove(ant, b.targets[ant], true) {
+		me.tryAnyMove(ant)
+	}
+}
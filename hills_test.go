@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+)
+
+//testBot returns a bot with the default config on an open map, with no ants.
+func testBot(rows, cols int) *GarboAnt {
+	s := &State{Rows: rows, Cols: cols, AttackRadius2: 5}
+	s.Map = NewMap(rows, cols)
+	s.Orders = NewOrderBook(s.Map)
+	c := DefaultConfig()
+	return &GarboAnt{
+		state:      s,
+		config:     c,
+		ants:       make(map[Location]*Ant),
+		paths:      NewPathfinder(s.Map),
+		regions:    NewRegions(s.Map),
+		terrain:    NewTerrain(s.Map, c.CorridorWidth),
+		knownHills: make(map[Location]Item),
+		strategy:   NewStrategy(),
+	}
+}
+
+//addAnt puts an ant of ours at row, col.
+func (me *GarboAnt) addAnt(row, col int) *Ant {
+	loc := me.state.Map.FromRowCol(row, col)
+	me.state.Map.AddAnt(loc, MY_ANT)
+	me.state.Map.AddDestination(loc)
+	ant := &Ant{loc: loc}
+	me.ants[loc] = ant
+	return ant
+}
+
+func TestDefend(t *testing.T) {
+	me := testBot(20, 20)
+	m := me.state.Map
+	hill, threat := m.FromRowCol(10, 10), m.FromRowCol(10, 16)
+	m.AddHill(hill, MY_HILL)
+	m.AddAnt(threat, ANT_1)
+	m.AddWater(m.FromRowCol(10, 13)) //right between them
+	near, next, far := me.addAnt(11, 10), me.addAnt(10, 8), me.addAnt(2, 2)
+
+	b := &DefendBehavior{}
+	b.Prepare(me)
+	if len(b.targets) != 2 || b.Bid(me, near) == 0 || b.Bid(me, next) == 0 || b.Bid(me, far) != 0 {
+		t.Errorf("%d defenders against one enemy, nearest bid %f, %f, furthest %f",
+			len(b.targets), b.Bid(me, near), b.Bid(me, next), b.Bid(me, far))
+	}
+	guard := b.targets[near]
+	threatDist := m.Distance2(hill, threat)
+	if m.Water.Has(guard) || m.Distance2(guard, hill) >= threatDist || m.Distance2(guard, threat) >= threatDist {
+		row, col := m.FromLocation(guard)
+		t.Errorf("guarding (%d, %d)", row, col)
+	}
+
+	//no raid outbids the defenders, whatever the weights
+	me.strategy.Register(b, me.config.DefendPriority)
+	me.strategy.Register(&RaidBehavior{}, me.config.RaidPriority)
+	me.strategy.SetWeight("defend", 0.5)
+	me.strategy.SetWeight("raid", 4)
+	if bid := 0.5 * b.Bid(me, near); bid <= 4 {
+		t.Errorf("defending bids %f against a raid ceiling of 4", bid)
+	}
+}
+
+func TestRaid(t *testing.T) {
+	me := testBot(40, 40)
+	m := me.state.Map
+	hill := m.FromRowCol(20, 20)
+	me.knownHills[hill] = HILL_1
+	for _, col := range []int{18, 19, 21} {
+		m.AddAnt(m.FromRowCol(22, col), ANT_1)
+	}
+	me.addAnt(20, 5)
+	me.addAnt(21, 5)
+
+	//two raiders don't take on three defenders
+	b := &RaidBehavior{}
+	b.Prepare(me)
+	for _, ant := range me.ants {
+		if b.Bid(me, ant) != 0 {
+			t.Errorf("raiding a hill with more defenders than AttackRatio allows")
+		}
+	}
+
+	//undefended, the nearer ants bid more for it
+	me = testBot(40, 40)
+	m = me.state.Map
+	me.knownHills[hill] = HILL_1
+	near, far := me.addAnt(20, 15), me.addAnt(20, 5)
+	b.Prepare(me)
+	if b.targets[near] != hill || b.Bid(me, near) <= b.Bid(me, far) || b.Bid(me, far) <= 0 {
+		t.Errorf("raid bids %f near the hill, %f further off", b.Bid(me, near), b.Bid(me, far))
+	}
+}
+
+func TestHillsOutOfSight(t *testing.T) {
+	me := testBot(40, 40)
+	m := me.state.Map
+	hill := m.FromRowCol(20, 20)
+	m.AddHill(hill, HILL_1)
+	m.AddLand(me.addAnt(20, 15).loc, 77)
+	me.updateHills()
+
+	//next turn the hill is out of sight, but the raid still goes for it
+	m.Reset()
+	me.ants = make(map[Location]*Ant)
+	ant := me.addAnt(20, 2)
+	m.AddLand(ant.loc, 77)
+	me.updateHills()
+	b := &RaidBehavior{}
+	b.Prepare(me)
+	if len(me.knownHills) != 1 || b.targets[ant] != hill || b.Bid(me, ant) <= 0 {
+		t.Errorf("forgot a hill that went out of sight")
+	}
+
+	//once its square is in sight with no hill on it, it's gone
+	m.Reset()
+	m.AddLand(m.FromRowCol(20, 17), 77)
+	me.updateHills()
+	if len(me.knownHills) != 0 {
+		t.Errorf("remembered a razed hill")
+	}
+}
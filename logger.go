@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type LogLevel int
+
+const (
+	LOG_DEBUG LogLevel = iota
+	LOG_INFO
+	LOG_WARN
+	LOG_ERROR
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+func (l LogLevel) String() string {
+	if l < LOG_DEBUG || l > LOG_ERROR {
+		return fmt.Sprintf("level%d", int(l))
+	}
+	return logLevelNames[l]
+}
+
+//ParseLogLevel turns "debug", "info", "warn" or "error" into a LogLevel.
+func ParseLogLevel(s string) (LogLevel, os.Error) {
+	for i, name := range logLevelNames {
+		if strings.ToLower(s) == name {
+			return LogLevel(i), nil
+		}
+	}
+	return LOG_DEBUG, fmt.Errorf("unknown log level %q", s)
+}
+
+//logSink is what all the loggers tagged from the same root share.
+type logSink struct {
+	w     io.Writer
+	level LogLevel
+	turn  int
+}
+
+//Logger writes leveled diagnostics, one line each, prefixed with the turn
+//and an optional tag (e.g. the ant it's about). A nil *Logger is a valid
+//logger that throws everything away without formatting it, which is what
+//the bot gets unless -log is given, so logging costs next to nothing in
+//competition. Anything expensive to build should still check Enabled first.
+type Logger struct {
+	sink *logSink
+	tag  string
+}
+
+//NewLogger returns a logger writing messages at level and above to w.
+func NewLogger(w io.Writer, level LogLevel) *Logger {
+	return &Logger{sink: &logSink{w: w, level: level}}
+}
+
+//SetTurn sets the turn the following messages are prefixed with, for l and
+//every logger tagged from it.
+func (l *Logger) SetTurn(turn int) {
+	if l == nil {
+		return
+	}
+	l.sink.turn = turn
+}
+
+//Enabled tells if messages at level would be written.
+func (l *Logger) Enabled(level LogLevel) bool {
+	return l != nil && level >= l.sink.level
+}
+
+//Tagged returns a logger which marks its messages with tag.
+func (l *Logger) Tagged(tag string) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.tag != "" {
+		tag = l.tag + " " + tag
+	}
+	return &Logger{l.sink, tag}
+}
+
+//Ant returns a logger tagged with the square of the ant at loc.
+func (l *Logger) Ant(m *Map, loc Location) *Logger {
+	if l == nil {
+		return nil
+	}
+	row, col := m.FromLocation(loc)
+	return l.Tagged(fmt.Sprintf("ant %d,%d", row, col))
+}
+
+func (l *Logger) logf(level LogLevel, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if l.tag != "" {
+		fmt.Fprintf(l.sink.w, "turn %d %-5s [%s] %s\n", l.sink.turn, level, l.tag, msg)
+	} else {
+		fmt.Fprintf(l.sink.w, "turn %d %-5s %s\n", l.sink.turn, level, msg)
+	}
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(LOG_DEBUG, format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(LOG_INFO, format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(LOG_WARN, format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(LOG_ERROR, format, args...)
+}
@@ -1,18 +1,108 @@
 package main
 
 import (
+	"flag"
+	"io"
 	"os"
 	"log"
+	"strings"
 )
 
 //main initializes the state and starts the processing loop
 func main() {
+	botName := flag.String("bot", "garbo", "which bot to run, one of "+strings.Join(BotNames(), ", "))
+	tournament := flag.String("tournament", "", "play a local tournament between -bots on these comma separated .map files instead of a game")
+	maps := flag.String("maps", "", "comma separated .map files for -optimize")
+	optimize := flag.String("optimize", "", "tune the config against -bots, checkpointing to this file (an existing one is resumed)")
+	bots := flag.String("bots", "garbo,random,greedy", "comma separated bots for the tournament, registered names or command lines")
+	games := flag.Int("games", 20, "number of tournament games, or games per candidate when optimizing")
+	seed := flag.Int64("seed", 1, "seed for the first tournament game")
+	generations := flag.Int("generations", 10, "number of generations to optimize for")
+	population := flag.Int("population", 12, "configs per generation when optimizing")
+	configFile := flag.String("config", "", "JSON file with strategy settings, anything left out keeps its default")
+	logFile := flag.String("log", "", "write the bot's log to this file, - for stderr, nothing is logged otherwise")
+	logLevel := flag.String("loglevel", "info", "lowest level to log: debug, info, warn or error")
+	statsFile := flag.String("stats", "", "write per-turn timings and counters to this file, as JSON lines if it ends in .json, CSV otherwise")
+	flag.Parse()
+
+	// Catch a bad -bot before the engine's handshake, not in the middle of it
+	if _, ok := botFactories[*botName]; !ok {
+		log.Panicf("Unknown bot %s, expected one of %s", *botName, strings.Join(BotNames(), ", "))
+	}
+	if *optimize != "" && *maps == "" {
+		log.Panicf("-optimize needs -maps to play the candidates on")
+	}
+
+	if *tournament != "" {
+		t := NewTournament(strings.Split(*tournament, ","), strings.Split(*bots, ","), *configFile, *seed)
+		t.Log = os.Stderr
+		err := t.Play(*games)
+		if err != nil {
+			log.Panicf("Tournament failed (%s)", err)
+		}
+		t.Report(os.Stdout)
+		return
+	}
+
+	if *optimize != "" {
+		o := NewOptimizer(strings.Split(*maps, ","), strings.Split(*bots, ","), *optimize)
+		o.Games = *games
+		o.Seed = *seed
+		o.Population = *population
+		o.Log = os.Stderr
+		err := o.Run(*generations)
+		if err != nil {
+			log.Panicf("Optimizer failed (%s)", err)
+		}
+		return
+	}
+
+	config := DefaultConfig()
+	if *configFile != "" {
+		var err os.Error
+		config, err = LoadConfig(*configFile)
+		if err != nil {
+			log.Panicf("LoadConfig() failed (%s)", err)
+		}
+	}
+
 	var s State
+	if *logFile != "" {
+		level, err := ParseLogLevel(*logLevel)
+		if err != nil {
+			log.Panicf("Bad -loglevel (%s)", err)
+		}
+		w := io.Writer(os.Stderr)
+		if *logFile != "-" {
+			f, err := os.Create(*logFile)
+			if err != nil {
+				log.Panicf("Can't create log file (%s)", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		s.Log = NewLogger(w, level)
+	}
 	err := s.Start()
 	if err != nil {
 		log.Panicf("Start() failed (%s)", err)
 	}
-	mb := NewBot(&s)
+	mb, err := MakeBot(*botName, &s, config)
+	if err != nil {
+		log.Panicf("MakeBot() failed (%s)", err)
+	}
+	if *statsFile != "" {
+		pb, ok := mb.(ProfiledBot)
+		if !ok {
+			log.Panicf("Bot %s doesn't keep statistics", *botName)
+		}
+		f, err := os.Create(*statsFile)
+		if err != nil {
+			log.Panicf("Can't create stats file (%s)", err)
+		}
+		defer f.Close()
+		pb.SetProfiler(NewProfiler(f, strings.HasSuffix(*statsFile, ".json")))
+	}
 	err = s.Loop(mb, func() {
 		//if you want to do other between-turn debugging things, you can do them here
 	})
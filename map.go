@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bytes"
 	"log"
+	"os"
 )
 
 //Item represents all the various items that may be on the map
@@ -155,50 +157,132 @@ func FromSymbol(ch byte) Item {
 	if ch < 'a' || ch > 'j' {
 		log.Panicf("invalid item symbol: %v", ch)
 	}
-	return Item(ch) + 'a'
+	return Item(ch - 'a')
 }
 
 
 //Location combines (Row, Col) coordinate pairs for use as keys in maps (and in a 1d array)
 type Location int
 
+//LocationSet is a set of locations on a map. Lookups, adds and removes are
+//O(1), iterating only touches the members and clearing doesn't allocate.
+type LocationSet struct {
+	index []int32 //position in list plus one for every location, 0 if absent
+	list  []Location
+}
+
+//NewLocationSet returns an empty set for a map with size squares.
+func NewLocationSet(size int) *LocationSet {
+	return &LocationSet{index: make([]int32, size)}
+}
+
+//Has returns true if loc is in the set.
+func (ls *LocationSet) Has(loc Location) bool {
+	return ls.index[loc] != 0
+}
+
+//Add puts loc in the set.
+func (ls *LocationSet) Add(loc Location) {
+	if ls.index[loc] == 0 {
+		ls.list = append(ls.list, loc)
+		ls.index[loc] = int32(len(ls.list))
+	}
+}
+
+//Remove takes loc out of the set.
+func (ls *LocationSet) Remove(loc Location) {
+	i := ls.index[loc] - 1
+	if i < 0 {
+		return
+	}
+	last := ls.list[len(ls.list)-1]
+	ls.list[i] = last
+	ls.index[last] = i + 1
+	ls.list = ls.list[:len(ls.list)-1]
+	ls.index[loc] = 0
+}
+
+//Clear empties the set.
+func (ls *LocationSet) Clear() {
+	for _, loc := range ls.list {
+		ls.index[loc] = 0
+	}
+	ls.list = ls.list[:0]
+}
+
+//Len returns the number of locations in the set.
+func (ls *LocationSet) Len() int {
+	return len(ls.list)
+}
+
+//Locations returns the members of the set, in no particular order. The slice
+//belongs to the set and is only good until it's next changed.
+func (ls *LocationSet) Locations() []Location {
+	return ls.list
+}
+
+//ItemSet is a LocationSet with an item (an ant or a hill) for each member.
+type ItemSet struct {
+	LocationSet
+	items []Item
+}
+
+//NewItemSet returns an empty set for a map with size squares.
+func NewItemSet(size int) *ItemSet {
+	return &ItemSet{LocationSet{index: make([]int32, size)}, make([]Item, size)}
+}
+
+//At returns the item at loc, or UNKNOWN if loc isn't in the set.
+func (is *ItemSet) At(loc Location) Item {
+	if !is.Has(loc) {
+		return UNKNOWN
+	}
+	return is.items[loc]
+}
+
+//Set puts loc in the set with the given item.
+func (is *ItemSet) Set(loc Location, item Item) {
+	is.Add(loc)
+	is.items[loc] = item
+}
+
 type Map struct {
 	Rows int
 	Cols int
 
 	itemGrid []Item
 
-	Ants         map[Location]Item
-	Hills        map[Location]Item
-	Dead         map[Location]Item
-	Water        map[Location]bool
-	Food         map[Location]bool
-	Destinations map[Location]bool
+	Ants         *ItemSet
+	Hills        *ItemSet
+	Dead         *ItemSet
+	Water        *LocationSet
+	Food         *LocationSet
+	Destinations *LocationSet
 }
 
 //NewMap returns a newly constructed blank map.
 func NewMap(Rows, Cols int) *Map {
 	m := &Map{
-		Rows:     Rows,
-		Cols:     Cols,
-		Water:    make(map[Location]bool),
-		itemGrid: make([]Item, Rows*Cols),
+		Rows:         Rows,
+		Cols:         Cols,
+		itemGrid:     make([]Item, Rows*Cols),
+		Ants:         NewItemSet(Rows * Cols),
+		Hills:        NewItemSet(Rows * Cols),
+		Dead:         NewItemSet(Rows * Cols),
+		Water:        NewLocationSet(Rows * Cols),
+		Food:         NewLocationSet(Rows * Cols),
+		Destinations: NewLocationSet(Rows * Cols),
 	}
 	m.Reset()
 	return m
 }
 
-//String returns an ascii diagram of the map.
+//String returns an ascii diagram of the map. Use a Renderer to write big
+//maps out, or only part of them.
 func (m *Map) String() string {
-	str := ""
-	for row := 0; row < m.Rows; row++ {
-		for col := 0; col < m.Cols; col++ {
-			s := m.itemGrid[row*m.Cols+col].Symbol()
-			str += string([]byte{s}) + " "
-		}
-		str += "\n"
-	}
-	return str
+	buf := bytes.NewBuffer(make([]byte, 0, m.Rows*(2*m.Cols+1)))
+	NewRenderer(m).WriteTo(buf)
+	return buf.String()
 }
 
 //Reset clears the map (except for water) for the next turn
@@ -206,16 +290,14 @@ func (m *Map) Reset() {
 	for i := range m.itemGrid {
 		m.itemGrid[i] = UNKNOWN
 	}
-	for i, val := range m.Water {
-		if val {
-			m.itemGrid[i] = WATER
-		}
+	for _, loc := range m.Water.Locations() {
+		m.itemGrid[loc] = WATER
 	}
-	m.Ants = make(map[Location]Item)
-	m.Hills = make(map[Location]Item)
-	m.Dead = make(map[Location]Item)
-	m.Food = make(map[Location]bool)
-	m.Destinations = make(map[Location]bool)
+	m.Ants.Clear()
+	m.Hills.Clear()
+	m.Dead.Clear()
+	m.Food.Clear()
+	m.Destinations.Clear()
 }
 
 //Item returns the item at a given location
@@ -225,17 +307,17 @@ func (m *Map) Item(loc Location) Item {
 
 //AddWater adds water to the map.
 func (m *Map) AddWater(loc Location) {
-	m.Water[loc] = true
+	m.Water.Add(loc)
 	m.itemGrid[loc] = WATER
 }
 
 //AddAnt adds an ant to the map. It can also accept an occupied ant hill.
 func (m *Map) AddAnt(loc Location, ant Item) {
-	m.Ants[loc] = ant.ToAnt()
+	m.Ants.Set(loc, ant.ToAnt())
 	if ant.IsOccupied() {
-		m.Hills[loc] = ant.ToUnoccupied()
+		m.Hills.Set(loc, ant.ToUnoccupied())
 	}
-	if m.Hills[loc] == ant.ToUnoccupied() {
+	if m.Hills.At(loc) == ant.ToUnoccupied() {
 		ant = ant.ToOccupied() //be sure to record the right thing in the itemGrid
 	}
 	m.itemGrid[loc] = ant
@@ -243,8 +325,8 @@ func (m *Map) AddAnt(loc Location, ant Item) {
 
 //AddHill takes an unoccupied ant hill and adds it to the map.
 func (m *Map) AddHill(loc Location, hill Item) {
-	m.Hills[loc] = hill.ToUnoccupied()
-	if m.Ants[loc] == hill.ToAnt() {
+	m.Hills.Set(loc, hill.ToUnoccupied())
+	if m.Ants.At(loc) == hill.ToAnt() {
 		hill = hill.ToOccupied() //an ant has already been added here!
 	}
 	m.itemGrid[loc] = hill
@@ -275,34 +357,34 @@ func (m *Map) DoInRad(center Location, rad2 int, Action func(row, col int)) {
 }
 
 func (m *Map) AddDeadAnt(loc Location, ant Item) {
-	m.Dead[loc] = ant
+	m.Dead.Set(loc, ant)
 	m.itemGrid[loc] = DEAD
 }
 
 func (m *Map) AddFood(loc Location) {
-	m.Food[loc] = true
+	m.Food.Add(loc)
 	m.itemGrid[loc] = FOOD
 }
 
 func (m *Map) AddDestination(loc Location) {
-	if m.Destinations[loc] {
+	if m.Destinations.Has(loc) {
 		log.Panicf("Already have something at that destination!")
 	}
-	m.Destinations[loc] = true
+	m.Destinations.Add(loc)
 }
 
 func (m *Map) RemoveDestination(loc Location) {
-	m.Destinations[loc] = false, false
+	m.Destinations.Remove(loc)
 }
 
 //SafeDestination will tell you if the given location is a 
 //safe place to dispatch an ant. It considers water and both
 //ants that have already sent an order and those that have not.
 func (m *Map) SafeDestination(loc Location) bool {
-	if _, exists := m.Water[loc]; exists {
+	if m.Water.Has(loc) {
 		return false
 	}
-	if occupied := m.Destinations[loc]; occupied {
+	if m.Destinations.Has(loc) {
 		return false
 	}
 	return true
@@ -334,6 +416,29 @@ func (m *Map) FromLocation(loc Location) (Row, Col int) {
 }
 
 
+//wrapDelta returns the shortest signed distance from a to b on a ring of
+//the given size.
+func wrapDelta(a, b, size int) int {
+	d := (b - a) % size
+	if d < 0 {
+		d += size
+	}
+	if d > size/2 {
+		d -= size
+	}
+	return d
+}
+
+//Distance2 returns the squared distance between two squares, the short way
+//round the map.
+func (m *Map) Distance2(a, b Location) int {
+	arow, acol := m.FromLocation(a)
+	brow, bcol := m.FromLocation(b)
+	dr := wrapDelta(arow, brow, m.Rows)
+	dc := wrapDelta(acol, bcol, m.Cols)
+	return dr*dr + dc*dc
+}
+
 //Direction represents the direction concept for issuing orders.
 type Direction int
 
@@ -363,6 +468,23 @@ func (d Direction) String() string {
 	return ""
 }
 
+//ParseDirection reverses Direction.String
+func ParseDirection(s string) (Direction, os.Error) {
+	switch s {
+	case "n":
+		return North, nil
+	case "s":
+		return South, nil
+	case "w":
+		return West, nil
+	case "e":
+		return East, nil
+	case "-":
+		return NoMovement, nil
+	}
+	return NoMovement, os.NewError("invalid direction: " + s)
+}
+
 //Move returns a new location which is one step in the specified direction from the specified location.
 func (m *Map) Move(loc Location, d Direction) Location {
 	Row, Col := m.FromLocation(loc)
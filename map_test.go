@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -60,3 +61,52 @@ A 0 .
 		t.Errorf("map put ants in wrong place, got `%s`", m)
 	}
 }
+
+func TestLocationSet(t *testing.T) {
+	ls := NewLocationSet(10)
+	ls.Add(3)
+	ls.Add(7)
+	ls.Add(5)
+	ls.Add(7)
+	if ls.Len() != 3 {
+		t.Errorf("expected 3 locations, got %v", ls.Locations())
+	}
+
+	ls.Remove(3)
+	ls.Remove(4)
+	if ls.Has(3) || !ls.Has(5) || !ls.Has(7) || ls.Len() != 2 {
+		t.Errorf("remove broken, got %v", ls.Locations())
+	}
+
+	ls.Clear()
+	if ls.Len() != 0 || ls.Has(5) || ls.Has(7) {
+		t.Errorf("clear broken, got %v", ls.Locations())
+	}
+
+	is := NewItemSet(10)
+	if is.At(2) != UNKNOWN {
+		t.Errorf("empty item set has %v", is.At(2))
+	}
+	is.Set(2, MY_ANT)
+	if is.At(2) != MY_ANT {
+		t.Errorf("item set lost an ant, got %v", is.At(2))
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	m := NewMap(5, 5)
+	m.AddWater(m.FromRowCol(0, 0))
+	m.AddAnt(m.FromRowCol(1, 1), MY_ANT)
+	m.AddFood(m.FromRowCol(4, 4))
+
+	buf := new(bytes.Buffer)
+	NewRenderer(m).Crop(m.FromRowCol(0, 0), 1).
+		Overlay(MarkOverlay([]Location{m.FromRowCol(0, 1)}, 'x')).
+		WriteTo(buf)
+	if buf.String() != `* . . 
+. % x 
+. . a 
+` {
+		t.Errorf("cropped render is wrong, got `%s`", buf)
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"json"
+	"math"
+	"os"
+	"rand"
+)
+
+//tunable is a numeric Config field the optimizer is allowed to change.
+type tunable struct {
+	name     string
+	min, max float64
+	integer  bool
+	get      func(c *Config) float64
+	set      func(c *Config, v float64)
+}
+
+var tunables = []tunable{
+	{"Areas", 4, 30, true,
+		func(c *Config) float64 { return float64(c.Areas) },
+		func(c *Config, v float64) { c.Areas = int(v) }},
+	{"MinHillConfidence", 0, 0.5, false,
+		func(c *Config) float64 { return c.MinHillConfidence },
+		func(c *Config, v float64) { c.MinHillConfidence = v }},
+	{"StaleTurns", 5, 100, true,
+		func(c *Config) float64 { return float64(c.StaleTurns) },
+		func(c *Config, v float64) { c.StaleTurns = int(v) }},
+	{"MaxFrontierClusters", 1, 50, true,
+		func(c *Config) float64 { return float64(c.MaxFrontierClusters) },
+		func(c *Config, v float64) { c.MaxFrontierClusters = int(v) }},
+	{"DangerDecay", 0.5, 1, false,
+		func(c *Config) float64 { return c.DangerDecay },
+		func(c *Config, v float64) { c.DangerDecay = v }},
+	{"FoodMaxDistance", 4, 30, true,
+		func(c *Config) float64 { return float64(c.FoodMaxDistance) },
+		func(c *Config, v float64) { c.FoodMaxDistance = int(v) }},
+	{"FoodContestedFactor", 0, 1, false,
+		func(c *Config) float64 { return c.FoodContestedFactor },
+		func(c *Config, v float64) { c.FoodContestedFactor = v }},
+	{"ThreatCost", 0, 20, false,
+		func(c *Config) float64 { return c.ThreatCost },
+		func(c *Config, v float64) { c.ThreatCost = v }},
+	{"DeathCost", 0, 20, false,
+		func(c *Config) float64 { return c.DeathCost },
+		func(c *Config, v float64) { c.DeathCost = v }},
+	{"CorridorCost", 0, 5, false,
+		func(c *Config) float64 { return c.CorridorCost },
+		func(c *Config, v float64) { c.CorridorCost = v }},
+	{"AttackReach", 0, 8, true,
+		func(c *Config) float64 { return float64(c.AttackReach) },
+		func(c *Config, v float64) { c.AttackReach = int(v) }},
+	{"AttackRatio", 1, 3, false,
+		func(c *Config) float64 { return c.AttackRatio },
+		func(c *Config, v float64) { c.AttackRatio = v }},
+	{"SkirmishLossWeight", 0.5, 2, false,
+		func(c *Config) float64 { return c.SkirmishLossWeight },
+		func(c *Config, v float64) { c.SkirmishLossWeight = v }},
+	{"SquadMinSize", 2, 6, true,
+		func(c *Config) float64 { return float64(c.SquadMinSize) },
+		func(c *Config, v float64) { c.SquadMinSize = int(v) }},
+	{"MidgameAnts", 10, 100, true,
+		func(c *Config) float64 { return float64(c.MidgameAnts) },
+		func(c *Config, v float64) { c.MidgameAnts = int(v) }},
+	{"EndgameTurns", 0.5, 1, false,
+		func(c *Config) float64 { return c.EndgameTurns },
+		func(c *Config, v float64) { c.EndgameTurns = v }},
+	{"RaidRadius2", 50, 2000, true,
+		func(c *Config) float64 { return float64(c.RaidRadius2) },
+		func(c *Config, v float64) { c.RaidRadius2 = int(v) }},
+	{"SquadPriority", 0, 50, true,
+		func(c *Config) float64 { return float64(c.SquadPriority) },
+		func(c *Config, v float64) { c.SquadPriority = int(v) }},
+	{"AttackPriority", 0, 50, true,
+		func(c *Config) float64 { return float64(c.AttackPriority) },
+		func(c *Config, v float64) { c.AttackPriority = int(v) }},
+	{"DefendPriority", 0, 50, true,
+		func(c *Config) float64 { return float64(c.DefendPriority) },
+		func(c *Config, v float64) { c.DefendPriority = int(v) }},
+	{"RaidPriority", 0, 50, true,
+		func(c *Config) float64 { return float64(c.RaidPriority) },
+		func(c *Config, v float64) { c.RaidPriority = int(v) }},
+	{"FoodPriority", 0, 50, true,
+		func(c *Config) float64 { return float64(c.FoodPriority) },
+		func(c *Config, v float64) { c.FoodPriority = int(v) }},
+	{"ExplorePriority", 0, 50, true,
+		func(c *Config) float64 { return float64(c.ExplorePriority) },
+		func(c *Config, v float64) { c.ExplorePriority = int(v) }},
+}
+
+//Mutations move each tunable by a normal step of this fraction of its range.
+const OPTIMIZER_MUTATION = 0.1
+
+//The best this many configs always survive into the next generation.
+const OPTIMIZER_ELITE = 2
+
+//Crossover gives up and copies the first parent after this many invalid
+//children.
+const OPTIMIZER_CROSSOVER_TRIES = 10
+
+//optimizerCheckpoint is everything needed to pick a search back up, it's
+//saved after every candidate is evaluated.
+type optimizerCheckpoint struct {
+	Generation  int
+	Population  []*Config
+	Fitness     []float64
+	Evaluated   int //members of Population with a Fitness
+	Best        *Config
+	BestFitness float64
+}
+
+//Optimizer runs a genetic search over the tunables, scoring each config by
+//playing GarboAnt with it against reference bots.
+type Optimizer struct {
+	Maps       []string
+	References [][]string //command lines of the reference bots
+	Games      int        //games played by each candidate
+	Population int
+	Seed       int64
+	Turns      int
+
+	Checkpoint string //file the search state is saved to and resumed from
+	Out        string //file the best config is written to
+
+	Log io.Writer //progress is written here if it isn't nil
+
+	cp optimizerCheckpoint
+}
+
+//NewOptimizer sets up a search against the given reference bots, which are
+//registered bot names or command lines like in a tournament.
+func NewOptimizer(maps []string, references []string, checkpoint string) *Optimizer {
+	o := &Optimizer{
+		Maps:       maps,
+		Games:      20,
+		Population: 12,
+		Seed:       1,
+		Turns:      500,
+		Checkpoint: checkpoint,
+		Out:        checkpoint + ".best.json",
+	}
+	for _, entry := range NewTournament(maps, references, "", 0).Entries {
+		o.References = append(o.References, entry.Command)
+	}
+	return o
+}
+
+func (o *Optimizer) logf(format string, args ...interface{}) {
+	if o.Log != nil {
+		fmt.Fprintf(o.Log, format, args...)
+	}
+}
+
+func writeJSON(fname string, v interface{}) os.Error {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+	//write then rename, so a crash never leaves half a checkpoint
+	if err = ioutil.WriteFile(fname+".tmp", data, 0666); err != nil {
+		return err
+	}
+	return os.Rename(fname+".tmp", fname)
+}
+
+//load resumes from the checkpoint file if there is one, otherwise starts
+//from the defaults and mutations of them.
+func (o *Optimizer) load() os.Error {
+	data, err := ioutil.ReadFile(o.Checkpoint)
+	if err == nil {
+		if err = json.Unmarshal(data, &o.cp); err != nil {
+			return fmt.Errorf("%s: %s", o.Checkpoint, err)
+		}
+		for _, c := range o.cp.Population {
+			if err = c.Validate(); err != nil {
+				return fmt.Errorf("%s: %s", o.Checkpoint, err)
+			}
+		}
+		o.logf("resuming generation %d at candidate %d\n", o.cp.Generation, o.cp.Evaluated)
+		return nil
+	}
+
+	r := rand.New(rand.NewSource(o.Seed))
+	o.cp = optimizerCheckpoint{BestFitness: -1}
+	o.cp.Population = []*Config{DefaultConfig()}
+	for len(o.cp.Population) < o.Population {
+		o.cp.Population = append(o.cp.Population, o.mutate(r, DefaultConfig()))
+	}
+	o.cp.Fitness = make([]float64, len(o.cp.Population))
+	return nil
+}
+
+//mutate returns a copy of c with every tunable nudged, kept in range.
+func (o *Optimizer) mutate(r *rand.Rand, c *Config) *Config {
+	mutant := *c
+	for _, t := range tunables {
+		v := t.get(c) + r.NormFloat64()*OPTIMIZER_MUTATION*(t.max-t.min)
+		v = math.Fmin(math.Fmax(v, t.min), t.max)
+		if t.integer {
+			v = math.Floor(v + 0.5)
+		}
+		t.set(&mutant, v)
+	}
+	if mutant.Validate() != nil {
+		return c
+	}
+	return &mutant
+}
+
+//crossover takes each tunable from one of the parents at random, keeping to
+//valid children.
+func (o *Optimizer) crossover(r *rand.Rand, a, b *Config) *Config {
+	// Mixing two valid configs can make an invalid one, so try a few times
+	for try := 0; try < OPTIMIZER_CROSSOVER_TRIES; try++ {
+		child := *a
+		for _, t := range tunables {
+			if r.Intn(2) == 1 {
+				t.set(&child, t.get(b))
+			}
+		}
+		if child.Validate() == nil {
+			return &child
+		}
+	}
+	return a
+}
+
+//evaluate plays c against the references and returns the average share of
+//the field it beat, 1 for winning every game and 0 for coming last in all.
+//Every candidate in a generation plays the same seeds.
+func (o *Optimizer) evaluate(c *Config) (float64, os.Error) {
+	candidate := o.Checkpoint + ".candidate.json"
+	if err := writeJSON(candidate, c); err != nil {
+		return 0, err
+	}
+	command := []string{os.Args[0], "-bot", "garbo", "-config", candidate}
+
+	total := 0.0
+	for g := 0; g < o.Games; g++ {
+		gm, err := LoadGameMap(o.Maps[g%len(o.Maps)])
+		if err != nil {
+			return 0, err
+		}
+		seat := g % gm.Players
+		names := []string{}
+		commands := [][]string{}
+		for i := 0; i < gm.Players; i++ {
+			if i == seat {
+				names = append(names, "candidate")
+				commands = append(commands, command)
+			} else {
+				ref := o.References[(g+i)%len(o.References)]
+				names = append(names, ref[len(ref)-1])
+				commands = append(commands, ref)
+			}
+		}
+
+		game := NewGame(gm, o.Seed+int64(o.cp.Generation*o.Games+g))
+		game.Turns = o.Turns
+		ranks, err := game.Play(names, commands)
+		if err != nil {
+			return 0, err
+		}
+		total += float64(gm.Players-1-ranks[seat]) / float64(gm.Players-1)
+	}
+	return total / float64(o.Games), nil
+}
+
+//breed replaces the population with the next generation: the elite carry
+//over and the rest are mutated children of tournament-selected parents.
+func (o *Optimizer) breed() {
+	r := rand.New(rand.NewSource(o.Seed + int64(o.cp.Generation) + 1))
+	pop, fit := o.cp.Population, o.cp.Fitness
+
+	order := make([]int, len(pop))
+	for i := range order {
+		order[i] = i
+	}
+	for i := range order {
+		for j := i + 1; j < len(order); j++ {
+			if fit[order[j]] > fit[order[i]] {
+				order[i], order[j] = order[j], order[i]
+			}
+		}
+	}
+
+	pick := func() *Config {
+		a, b := r.Intn(len(pop)), r.Intn(len(pop))
+		if fit[b] > fit[a] {
+			a = b
+		}
+		return pop[a]
+	}
+
+	next := []*Config{}
+	for i := 0; i < OPTIMIZER_ELITE && i < len(order); i++ {
+		next = append(next, pop[order[i]])
+	}
+	for len(next) < o.Population {
+		next = append(next, o.mutate(r, o.crossover(r, pick(), pick())))
+	}
+
+	o.cp.Population = next
+	o.cp.Fitness = make([]float64, len(next))
+	o.cp.Evaluated = 0
+	o.cp.Generation++
+}
+
+//Run searches until generations generations have been evaluated, picking
+//up from the checkpoint if one exists. The best config found so far is
+//written to Out after every generation.
+func (o *Optimizer) Run(generations int) os.Error {
+	if len(o.Maps) == 0 || len(o.References) == 0 {
+		return os.NewError("optimizer needs at least one map and one reference bot")
+	}
+	if err := o.load(); err != nil {
+		return err
+	}
+
+	for o.cp.Generation < generations {
+		for o.cp.Evaluated < len(o.cp.Population) {
+			i := o.cp.Evaluated
+			fitness, err := o.evaluate(o.cp.Population[i])
+			if err != nil {
+				return err
+			}
+			o.cp.Fitness[i] = fitness
+			o.cp.Evaluated++
+			o.logf("generation %d candidate %d: %.3f\n", o.cp.Generation, i, fitness)
+
+			if fitness > o.cp.BestFitness {
+				o.cp.Best = o.cp.Population[i]
+				o.cp.BestFitness = fitness
+			}
+			if err = writeJSON(o.Checkpoint, &o.cp); err != nil {
+				return err
+			}
+		}
+
+		if err := writeJSON(o.Out, o.cp.Best); err != nil {
+			return err
+		}
+		o.logf("generation %d best so far: %.3f\n", o.cp.Generation, o.cp.BestFitness)
+
+		o.breed()
+		if err := writeJSON(o.Checkpoint, &o.cp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
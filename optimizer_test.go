@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"rand"
+	"reflect"
+	"testing"
+)
+
+func TestOptimizerMutate(t *testing.T) {
+	o := &Optimizer{}
+	r := rand.New(rand.NewSource(1))
+	c := DefaultConfig()
+	for i := 0; i < 200; i++ {
+		c = o.mutate(r, c)
+		if err := c.Validate(); err != nil {
+			t.Fatalf("mutation %d is invalid (%s)", i, err)
+		}
+		for _, tn := range tunables {
+			v := tn.get(c)
+			if v < tn.min || v > tn.max {
+				t.Errorf("mutation %d: %s = %v outside [%v, %v]", i, tn.name, v, tn.min, tn.max)
+			}
+			if tn.integer && v != math.Floor(v) {
+				t.Errorf("mutation %d: %s = %v isn't whole", i, tn.name, v)
+			}
+		}
+	}
+}
+
+func TestOptimizerCrossover(t *testing.T) {
+	o := &Optimizer{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		a, b := o.mutate(r, DefaultConfig()), o.mutate(r, DefaultConfig())
+		child := o.crossover(r, a, b)
+		if err := child.Validate(); err != nil {
+			t.Fatalf("child %d is invalid (%s)", i, err)
+		}
+		for _, tn := range tunables {
+			if v := tn.get(child); v != tn.get(a) && v != tn.get(b) {
+				t.Errorf("child %d: %s = %v, parents had %v and %v", i, tn.name, v, tn.get(a), tn.get(b))
+			}
+		}
+	}
+}
+
+func TestOptimizerResume(t *testing.T) {
+	checkpoint := path.Join(os.TempDir(), fmt.Sprintf("garboant-optimizer-%d.json", os.Getpid()))
+	defer os.Remove(checkpoint)
+
+	//a search stopped part way through a generation...
+	o := &Optimizer{Population: 4, Seed: 1, Checkpoint: checkpoint}
+	if err := o.load(); err != nil {
+		t.Fatalf("load() failed (%s)", err)
+	}
+	o.cp.Fitness[0], o.cp.Fitness[1] = 0.25, 0.75
+	o.cp.Evaluated = 2
+	o.cp.Best, o.cp.BestFitness = o.cp.Population[1], 0.75
+	o.breed()
+	o.cp.Fitness[0] = 0.5
+	o.cp.Evaluated = 1
+	if err := writeJSON(checkpoint, &o.cp); err != nil {
+		t.Fatalf("writeJSON() failed (%s)", err)
+	}
+
+	//...picks up at the same generation and candidate
+	resumed := &Optimizer{Population: 4, Seed: 2, Checkpoint: checkpoint}
+	if err := resumed.load(); err != nil {
+		t.Fatalf("load() failed (%s)", err)
+	}
+	if resumed.cp.Generation != 1 || resumed.cp.Evaluated != 1 {
+		t.Errorf("resumed at generation %d candidate %d, expected 1 and 1", resumed.cp.Generation, resumed.cp.Evaluated)
+	}
+	if !reflect.DeepEqual(resumed.cp, o.cp) {
+		t.Errorf("resumed search state differs from the one saved")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+//Order is a single move for one of our ants.
+type Order struct {
+	Loc Location
+	Dir Direction
+}
+
+//RejectedOrder is an order the book refused, and why.
+type RejectedOrder struct {
+	Order
+	Reason string
+}
+
+//OrderBook collects the turn's orders, checking each against the map as it
+//comes in. Only the orders that pass are sent, when the turn ends.
+type OrderBook struct {
+	m *Map
+
+	orders   []Order
+	ordered  map[Location]bool
+	Rejected []RejectedOrder
+}
+
+//NewOrderBook returns an empty order book for m.
+func NewOrderBook(m *Map) *OrderBook {
+	ob := &OrderBook{m: m}
+	ob.Reset()
+	return ob
+}
+
+//Reset clears the book for the next turn.
+func (ob *OrderBook) Reset() {
+	ob.orders = ob.orders[:0]
+	ob.ordered = make(map[Location]bool)
+	ob.Rejected = ob.Rejected[:0]
+}
+
+//Add validates an order for the ant at loc. Accepted orders update the map's
+//destinations, rejected ones are recorded in Rejected and returned as an error.
+func (ob *OrderBook) Add(loc Location, d Direction) os.Error {
+	reason := ""
+	dest := ob.m.Move(loc, d)
+	switch {
+	case ob.m.Ants.At(loc) != MY_ANT:
+		reason = "no ant of ours there"
+	case ob.ordered[loc]:
+		reason = "ant already has an order"
+	case d == NoMovement:
+		reason = "not a move"
+	case ob.m.Water.Has(dest):
+		reason = "moves into water"
+	case ob.m.Destinations.Has(dest):
+		reason = "another ant is going there"
+	}
+	if reason != "" {
+		ob.Rejected = append(ob.Rejected, RejectedOrder{Order{loc, d}, reason})
+		row, col := ob.m.FromLocation(loc)
+		return fmt.Errorf("order %d %d %s rejected: %s", row, col, d, reason)
+	}
+
+	ob.m.RemoveDestination(loc)
+	ob.m.AddDestination(dest)
+	ob.ordered[loc] = true
+	ob.orders = append(ob.orders, Order{loc, d})
+	return nil
+}
+
+//Orders returns the orders accepted so far this turn.
+func (ob *OrderBook) Orders() []Order {
+	return ob.orders
+}
+
+//Flush writes the accepted orders in the protocol's format.
+func (ob *OrderBook) Flush(w io.Writer) {
+	for _, o := range ob.orders {
+		row, col := ob.m.FromLocation(o.Loc)
+		fmt.Fprintf(w, "o %d %d %s\n", row, col, o.Dir)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOrderBook(t *testing.T) {
+	m := NewMap(4, 4)
+	ob := NewOrderBook(m)
+	ant := m.FromRowCol(1, 1)
+	other := m.FromRowCol(1, 2)
+	m.AddWater(m.FromRowCol(0, 1))
+	m.AddAnt(ant, MY_ANT)
+	m.AddDestination(ant)
+	m.AddAnt(other, MY_ANT)
+	m.AddDestination(other)
+	m.AddAnt(m.FromRowCol(3, 3), ANT_1)
+
+	if ob.Add(ant, North) == nil {
+		t.Errorf("accepted a move into water")
+	}
+	if ob.Add(ant, East) == nil {
+		t.Errorf("accepted a move onto another ant")
+	}
+	if ob.Add(m.FromRowCol(2, 2), East) == nil {
+		t.Errorf("accepted an order for an empty square")
+	}
+	if ob.Add(m.FromRowCol(3, 3), East) == nil {
+		t.Errorf("accepted an order for an enemy ant")
+	}
+	if err := ob.Add(ant, South); err != nil {
+		t.Errorf("rejected a legal move (%s)", err)
+	}
+	if ob.Add(ant, West) == nil {
+		t.Errorf("accepted a second order for the same ant")
+	}
+	if err := ob.Add(other, West); err != nil {
+		t.Errorf("rejected a move into a square that was vacated (%s)", err)
+	}
+	if len(ob.Rejected) != 5 {
+		t.Errorf("recorded %d rejected orders, wanted 5", len(ob.Rejected))
+	}
+
+	out := new(bytes.Buffer)
+	ob.Flush(out)
+	if out.String() != "o 1 1 s\no 1 2 w\n" {
+		t.Errorf("flushed `%s`", out)
+	}
+}
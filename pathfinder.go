@@ -0,0 +1,205 @@
+package main
+
+import (
+	"container/heap"
+	"container/list"
+	"math"
+)
+
+//CostLayer adds to the cost of stepping onto a square. Layers should never
+//return less than 0.
+type CostLayer func(loc Location) float64
+
+//Pathfinder finds the cheapest paths over the map with A*. Every step costs
+//1 plus whatever the cost layers add for the square stepped onto, so with no
+//layers it finds the same shortest paths as a BFS. Only known water is
+//impassable.
+type Pathfinder struct {
+	m      *Map
+	layers []CostLayer
+
+	//what the threat and hill layers cost, kept from turn to turn
+	threat   []float32
+	nearHill *LocationSet
+
+	//scratch space, reused from search to search by bumping search
+	search  int32
+	reached []int32
+	closed  []int32
+	cost    []float64
+	from    []Direction
+
+	Expansions int //squares expanded over all searches
+}
+
+//NewPathfinder returns a pathfinder for m with no cost layers.
+func NewPathfinder(m *Map) *Pathfinder {
+	size := m.Rows * m.Cols
+	return &Pathfinder{
+		m:        m,
+		threat:   make([]float32, size),
+		nearHill: NewLocationSet(size),
+		reached:  make([]int32, size),
+		closed:   make([]int32, size),
+		cost:     make([]float64, size),
+		from:     make([]Direction, size),
+	}
+}
+
+//AddLayer adds l to the cost of every step.
+func (pf *Pathfinder) AddLayer(l CostLayer) {
+	pf.layers = append(pf.layers, l)
+}
+
+//ClearLayers takes all the cost layers away.
+func (pf *Pathfinder) ClearLayers() {
+	pf.layers = pf.layers[:0]
+}
+
+//Cost is what stepping onto loc costs.
+func (pf *Pathfinder) Cost(loc Location) float64 {
+	cost := 1.0
+	for _, l := range pf.layers {
+		cost += l(loc)
+	}
+	return cost
+}
+
+//estimate is the wrapped manhattan distance between two squares, which
+//never overestimates since no step costs less than 1.
+func (pf *Pathfinder) estimate(a, b Location) float64 {
+	arow, acol := pf.m.FromLocation(a)
+	brow, bcol := pf.m.FromLocation(b)
+	dr := wrapDelta(arow, brow, pf.m.Rows)
+	dc := wrapDelta(acol, bcol, pf.m.Cols)
+	return math.Fabs(float64(dr)) + math.Fabs(float64(dc))
+}
+
+type pathNode struct {
+	loc      Location
+	priority float64
+}
+
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathNode)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+//Search returns the cheapest path from source to target as a list of
+//Directions, and false if there is none.
+func (pf *Pathfinder) Search(source, target Location) (*list.List, bool) {
+	return pf.find(source, target, false)
+}
+
+//SearchFree returns the shortest path from source to target leaving out the
+//cost layers, for ants we mean to send into the enemies the layers keep
+//other paths away from.
+func (pf *Pathfinder) SearchFree(source, target Location) (*list.List, bool) {
+	return pf.find(source, target, true)
+}
+
+func (pf *Pathfinder) find(source, target Location, free bool) (*list.List, bool) {
+	if source == target {
+		return nil, false
+	}
+
+	pf.search++
+	pf.reached[source] = pf.search
+	pf.cost[source] = 0
+	open := &pathQueue{{source, pf.estimate(source, target)}}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(pathNode).loc
+		if pf.closed[current] == pf.search {
+			continue
+		}
+		pf.closed[current] = pf.search
+		pf.Expansions++
+
+		if current == target {
+			path := new(list.List)
+			for loc := target; loc != source; {
+				dir := pf.from[loc]
+				path.PushFront(dir)
+				loc = pf.m.Move(loc, (dir+2)%4)
+			}
+			return path, true
+		}
+
+		for dir := North; dir <= West; dir++ {
+			next := pf.m.Move(current, dir)
+			if pf.m.Water.Has(next) || pf.closed[next] == pf.search {
+				continue
+			}
+			step := 1.0
+			if !free {
+				step = pf.Cost(next)
+			}
+			cost := pf.cost[current] + step
+			if pf.reached[next] != pf.search || cost < pf.cost[next] {
+				pf.reached[next] = pf.search
+				pf.cost[next] = cost
+				pf.from[next] = dir
+				heap.Push(open, pathNode{next, cost + pf.estimate(next, target)})
+			}
+		}
+	}
+	return nil, false
+}
+
+//ThreatLayer costs weight for every enemy ant that could attack a square
+//after one move.
+func (pf *Pathfinder) ThreatLayer(attackRadius2 int, weight float64) CostLayer {
+	m := pf.m
+	reach := math.Sqrt(float64(attackRadius2)) + 1
+	threat := pf.threat
+	for i := range threat {
+		threat[i] = 0
+	}
+	for _, loc := range m.Ants.Locations() {
+		if m.Ants.At(loc).ToAnt() == MY_ANT {
+			continue
+		}
+		m.DoInRad(loc, int(reach*reach), func(row, col int) {
+			threat[m.FromRowCol(row, col)] += float32(weight)
+		})
+	}
+	return func(loc Location) float64 {
+		return float64(threat[loc])
+	}
+}
+
+//DeathLayer costs weight for each unit of danger the casualties analyzer
+//has in a square.
+func DeathLayer(ca *Casualties, weight float64) CostLayer {
+	return func(loc Location) float64 {
+		return weight * float64(ca.Danger[loc])
+	}
+}
+
+//HillLayer costs weight for the squares within radius2 of any of hills,
+//which tend to be well defended.
+func (pf *Pathfinder) HillLayer(hills map[Location]Item, radius2 int, weight float64) CostLayer {
+	m := pf.m
+	near := pf.nearHill
+	near.Clear()
+	for loc := range hills {
+		m.DoInRad(loc, radius2, func(row, col int) {
+			near.Add(m.FromRowCol(row, col))
+		})
+	}
+	return func(loc Location) float64 {
+		if near.Has(loc) {
+			return weight
+		}
+		return 0
+	}
+}
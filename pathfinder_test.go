@@ -0,0 +1,74 @@
+package main
+
+import (
+	"container/list"
+	"testing"
+)
+
+//walk follows path from start, failing on water.
+func walk(t *testing.T, m *Map, start Location, path *list.List) (Location, []Location) {
+	loc := start
+	squares := []Location{}
+	for e := path.Front(); e != nil; e = e.Next() {
+		loc = m.Move(loc, e.Value.(Direction))
+		if m.Water.Has(loc) {
+			t.Fatalf("path goes through water")
+		}
+		squares = append(squares, loc)
+	}
+	return loc, squares
+}
+
+func TestPathfinder(t *testing.T) {
+	m := NewMap(12, 20)
+	for row := 2; row < 9; row++ {
+		m.AddWater(m.FromRowCol(row, 5))
+	}
+	pf := NewPathfinder(m)
+	source, target := m.FromRowCol(5, 3), m.FromRowCol(5, 7)
+
+	path, ok := pf.Search(source, target)
+	if !ok {
+		t.Fatalf("no path around the wall")
+	}
+	if end, _ := walk(t, m, source, path); end != target {
+		t.Fatalf("path ends at %v, want %v", end, target)
+	}
+	if path.Len() != 12 {
+		t.Errorf("path around the wall is %d steps, want 12", path.Len())
+	}
+
+	//an enemy at the top of the wall should send us round the bottom
+	enemy := m.FromRowCol(1, 5)
+	m.AddAnt(enemy, ANT_1)
+	pf.AddLayer(pf.ThreatLayer(5, 10))
+	path, ok = pf.Search(source, target)
+	if !ok {
+		t.Fatalf("no path with the threat layer")
+	}
+	end, squares := walk(t, m, source, path)
+	if end != target {
+		t.Fatalf("path ends at %v, want %v", end, target)
+	}
+	for _, loc := range squares {
+		if row, _ := m.FromLocation(loc); row < 5 {
+			t.Errorf("path went past the enemy at the top of the wall")
+			break
+		}
+	}
+
+	//unless we mean to fight it
+	fight := m.FromRowCol(1, 7)
+	path, ok = pf.SearchFree(source, fight)
+	if !ok || path.Len() != 8 {
+		t.Fatalf("cost-free path isn't the shortest one over the top")
+	}
+	if end, _ := walk(t, m, source, path); end != fight {
+		t.Fatalf("path ends at %v, want %v", end, fight)
+	}
+
+	pf.ClearLayers()
+	if pf.Cost(m.FromRowCol(1, 4)) != 1 {
+		t.Errorf("cost still includes the cleared layers")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+//GamePhase is how far along the game is, as far as strategy is concerned.
+type GamePhase int
+
+const (
+	PHASE_OPENING   GamePhase = iota //a handful of ants, grow as fast as we can
+	PHASE_EXPANSION                  //spread out over the map
+	PHASE_MIDGAME                    //an enemy hill to go for, or ants to spare
+	PHASE_ENDGAME                    //all in on the enemy hills
+	NUM_PHASES
+)
+
+var phaseNames = []string{"opening", "expansion", "midgame", "endgame"}
+
+func (p GamePhase) String() string {
+	if p < 0 || p >= NUM_PHASES {
+		return "unknown"
+	}
+	return phaseNames[p]
+}
+
+//PhaseWeights are what the bids of the behaviors a phase shifts ants between
+//are multiplied by.
+type PhaseWeights struct {
+	Food    float64
+	Explore float64
+	Defend  float64
+	Raid    float64
+}
+
+//PhaseController works out the phase of the game each turn and weights the
+//strategy's behaviors for it. Phases only ever move on, so losing a few ants
+//in the midgame doesn't send everyone back to gathering food. The score only
+//comes at the end of the game, so ants and hills stand in for it.
+type PhaseController struct {
+	Phase GamePhase
+
+	openingAnts    int     //ants it takes to end the opening
+	midgameAnts    int     //ants it takes to start the midgame without a hill to go for
+	endgameTurns   float64 //fraction of the game after which it's the endgame
+	dominanceRatio float64 //our ants per enemy that make it the endgame early
+	weights        []PhaseWeights
+}
+
+//NewPhaseController returns a controller in the opening, with the thresholds
+//and weights from c.
+func NewPhaseController(c *Config) *PhaseController {
+	return &PhaseController{
+		openingAnts:    c.OpeningAnts,
+		midgameAnts:    c.MidgameAnts,
+		endgameTurns:   c.EndgameTurns,
+		dominanceRatio: c.DominanceRatio,
+		weights:        c.PhaseWeights[:],
+	}
+}
+
+//next returns the phase the game is in, given the turn out of turns, how
+//many ants we and the enemies we can see have, and how many enemy hills we
+//know of, in sight or not, until they're razed.
+func (pc *PhaseController) next(turn, turns, myAnts, enemyAnts, enemyHills int) GamePhase {
+	phase := PHASE_OPENING
+	switch {
+	case turns > 0 && float64(turn) >= pc.endgameTurns*float64(turns):
+		phase = PHASE_ENDGAME
+	case enemyHills > 0 && myAnts >= pc.midgameAnts && float64(myAnts) >= pc.dominanceRatio*float64(enemyAnts):
+		// Nothing left that can stop us
+		phase = PHASE_ENDGAME
+	case enemyHills > 0 || myAnts >= pc.midgameAnts:
+		phase = PHASE_MIDGAME
+	case myAnts >= pc.openingAnts:
+		phase = PHASE_EXPANSION
+	}
+	if phase < pc.Phase {
+		return pc.Phase
+	}
+	return phase
+}
+
+//Update moves the game on to the next phase if it's time, and sets the
+//weights of st's behaviors for the phase it's in. It returns true if the
+//phase changed.
+func (pc *PhaseController) Update(st *Strategy, turn, turns, myAnts, enemyAnts, enemyHills int) bool {
+	phase := pc.next(turn, turns, myAnts, enemyAnts, enemyHills)
+	changed := phase != pc.Phase
+	pc.Phase = phase
+
+	w := pc.weights[phase]
+	st.SetWeight("food", w.Food)
+	st.SetWeight("explore", w.Explore)
+	st.SetWeight("defend", w.Defend)
+	st.SetWeight("raid", w.Raid)
+	return changed
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPhaseController(t *testing.T) {
+	c := DefaultConfig()
+	pc := NewPhaseController(c)
+	st := NewStrategy()
+	raid := &RaidBehavior{}
+	st.Register(raid, c.RaidPriority)
+
+	steps := []struct {
+		turn, myAnts, enemyAnts, enemyHills int
+		want                                GamePhase
+	}{
+		{1, 1, 0, 0, PHASE_OPENING},
+		{40, c.OpeningAnts, 5, 0, PHASE_EXPANSION},
+		{50, c.OpeningAnts - 2, 5, 0, PHASE_EXPANSION}, //losing ants doesn't go back
+		{60, c.OpeningAnts, 5, 1, PHASE_MIDGAME},
+		{70, c.MidgameAnts, c.MidgameAnts, 1, PHASE_MIDGAME},
+		{80, c.MidgameAnts, 1, 1, PHASE_ENDGAME},
+	}
+	for _, step := range steps {
+		pc.Update(st, step.turn, 1000, step.myAnts, step.enemyAnts, step.enemyHills)
+		if pc.Phase != step.want {
+			t.Errorf("turn %d: %s, want %s", step.turn, pc.Phase, step.want)
+		}
+	}
+
+	//the end of the game is the endgame whatever else is going on
+	pc = NewPhaseController(c)
+	if changed := pc.Update(st, 900, 1000, 1, 10, 0); !changed || pc.Phase != PHASE_ENDGAME {
+		t.Errorf("turn 900 of 1000: %s", pc.Phase)
+	}
+	if st.behaviors[0].weight != c.PhaseWeights[PHASE_ENDGAME].Raid {
+		t.Errorf("raid weight %f in the endgame", st.behaviors[0].weight)
+	}
+}
+
+func TestPhaseHillsOutOfSight(t *testing.T) {
+	me := testBot(40, 40)
+	m := me.state.Map
+	pc := NewPhaseController(me.config)
+	me.strategy.Register(&RaidBehavior{}, me.config.RaidPriority)
+
+	//the midgame starts with an enemy hill in sight, and lasts once it isn't
+	m.AddHill(m.FromRowCol(20, 20), HILL_1)
+	m.AddLand(me.addAnt(20, 15).loc, 77)
+	for turn := 60; turn < 63; turn++ {
+		me.updateHills()
+		pc.Update(me.strategy, turn, 1000, me.config.OpeningAnts, 5, len(me.knownHills))
+		if len(me.knownHills) != 1 || pc.Phase != PHASE_MIDGAME {
+			t.Errorf("turn %d: %d hills known, %s", turn, len(me.knownHills), pc.Phase)
+		}
+		if w := me.strategy.Weight("raid"); w != me.config.PhaseWeights[PHASE_MIDGAME].Raid {
+			t.Errorf("turn %d: raid weight %f", turn, w)
+		}
+		m.Reset()
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+//Profiler times the phases of a turn and counts events (BFS expansions,
+//path rebuilds...) in them, writing a record for every turn. All the
+//methods do nothing on a nil *Profiler, so a bot can call them freely
+//whether or not stats were asked for.
+type Profiler struct {
+	w    io.Writer
+	json bool //JSON lines if set, otherwise CSV rows of turn,name,value
+
+	phase      string
+	phaseStart int64
+	phases     []string //in the order they first ran this turn
+	times      map[string]int64
+	counts     map[string]int
+	header     bool
+}
+
+//ProfiledBot is a bot that can report its turn statistics.
+type ProfiledBot interface {
+	SetProfiler(p *Profiler)
+}
+
+//NewProfiler returns a profiler writing to w, as JSON lines if json is set
+//or CSV otherwise.
+func NewProfiler(w io.Writer, json bool) *Profiler {
+	return &Profiler{
+		w:      w,
+		json:   json,
+		times:  make(map[string]int64),
+		counts: make(map[string]int),
+	}
+}
+
+//Begin ends the current phase, if any, and starts timing the named one.
+func (p *Profiler) Begin(phase string) {
+	if p == nil {
+		return
+	}
+	p.End()
+	p.phase = phase
+	p.phaseStart = time.Nanoseconds()
+	if _, seen := p.times[phase]; !seen {
+		p.phases = append(p.phases, phase)
+		p.times[phase] = 0
+	}
+}
+
+//End stops timing the current phase.
+func (p *Profiler) End() {
+	if p == nil || p.phase == "" {
+		return
+	}
+	p.times[p.phase] += time.Nanoseconds() - p.phaseStart
+	p.phase = ""
+}
+
+//Count adds n to the named counter.
+func (p *Profiler) Count(name string, n int) {
+	if p == nil {
+		return
+	}
+	p.counts[name] += n
+}
+
+//EndTurn writes out the turn's record and starts afresh.
+func (p *Profiler) EndTurn(turn int) {
+	if p == nil {
+		return
+	}
+	p.End()
+
+	counters := []string{}
+	for name := range p.counts {
+		counters = append(counters, name)
+	}
+	sort.Strings(counters)
+
+	if p.json {
+		fmt.Fprintf(p.w, `{"turn": %d, "ms": {`, turn)
+		for i, phase := range p.phases {
+			if i > 0 {
+				fmt.Fprintf(p.w, ", ")
+			}
+			fmt.Fprintf(p.w, `"%s": %.3f`, phase, float64(p.times[phase])/1e6)
+		}
+		fmt.Fprintf(p.w, `}, "counts": {`)
+		for i, name := range counters {
+			if i > 0 {
+				fmt.Fprintf(p.w, ", ")
+			}
+			fmt.Fprintf(p.w, `"%s": %d`, name, p.counts[name])
+		}
+		fmt.Fprintf(p.w, "}}\n")
+	} else {
+		if !p.header {
+			fmt.Fprintf(p.w, "turn,name,value\n")
+			p.header = true
+		}
+		for _, phase := range p.phases {
+			fmt.Fprintf(p.w, "%d,ms.%s,%.3f\n", turn, phase, float64(p.times[phase])/1e6)
+		}
+		for _, name := range counters {
+			fmt.Fprintf(p.w, "%d,%s,%d\n", turn, name, p.counts[name])
+		}
+	}
+
+	p.phases = p.phases[:0]
+	p.times = make(map[string]int64)
+	p.counts = make(map[string]int)
+}
@@ -0,0 +1,151 @@
+package main
+
+//Regions labels the connected pieces of land on the map, land being every
+//square not known to be water, so whether there is a path between two
+//squares can be answered without searching for one. Water is only ever
+//added, so regions only ever split: each new water square is checked for
+//whether it could cut its region in two, and only then is the region
+//flooded again. Targets found unreachable are blacklisted for the region
+//they were asked from.
+type Regions struct {
+	m         *Map
+	label     []int //region of each square, 0 for water
+	next      int   //last label handed out
+	pending   []Location
+	blacklist map[regionTarget]bool //targets that can't be reached from a region
+	queue     []Location            //scratch for flood
+
+	Floods   int //squares relabelled over all updates
+	Rejected int //targets found unreachable
+}
+
+type regionTarget struct {
+	region int
+	target Location
+}
+
+//NewRegions returns the regions of m with no water known, one region
+//covering the whole map.
+func NewRegions(m *Map) *Regions {
+	r := &Regions{
+		m:         m,
+		label:     make([]int, m.Rows*m.Cols),
+		next:      1,
+		blacklist: make(map[regionTarget]bool),
+	}
+	for i := range r.label {
+		r.label[i] = 1
+	}
+	return r
+}
+
+//splits tells if making loc water could split its region, that is if the
+//land around it isn't joined up around loc itself.
+func (r *Regions) splits(loc Location) bool {
+	land := func(loc Location) bool { return r.label[loc] != 0 }
+	sides, joins := 0, 0
+	for dir := North; dir <= West; dir++ {
+		side := r.m.Move(loc, dir)
+		if !land(side) {
+			continue
+		}
+		sides++
+		next := r.m.Move(loc, (dir+1)%4)
+		if land(next) && land(r.m.Move(side, (dir+1)%4)) {
+			joins++
+		}
+	}
+	return sides-joins > 1
+}
+
+//AddWater marks loc as water, to be taken into account by the next Update.
+func (r *Regions) AddWater(loc Location) {
+	if r.label[loc] == 0 {
+		return
+	}
+	split := r.splits(loc)
+	r.label[loc] = 0
+	if split {
+		r.pending = append(r.pending, loc)
+	}
+}
+
+//flood relabels the squares labelled from that are connected to start.
+func (r *Regions) flood(start Location, from, to int) {
+	r.queue = append(r.queue[:0], start)
+	r.label[start] = to
+	for len(r.queue) > 0 {
+		loc := r.queue[len(r.queue)-1]
+		r.queue = r.queue[:len(r.queue)-1]
+		r.Floods++
+		for dir := North; dir <= West; dir++ {
+			next := r.m.Move(loc, dir)
+			if r.label[next] == from {
+				r.label[next] = to
+				r.queue = append(r.queue, next)
+			}
+		}
+	}
+}
+
+//Update relabels the regions the water added since the last update might
+//have split. Every piece gets a fresh label, even if it turns out the region
+//was still in one piece.
+func (r *Regions) Update() {
+	if len(r.pending) == 0 {
+		return
+	}
+	split := make(map[int]bool)
+	for _, loc := range r.pending {
+		fresh := make(map[int]bool)
+		for dir := North; dir <= West; dir++ {
+			next := r.m.Move(loc, dir)
+			if l := r.label[next]; l != 0 && !fresh[l] {
+				r.next++
+				r.flood(next, l, r.next)
+				fresh[r.next] = true
+				split[l] = true
+			}
+		}
+	}
+	r.pending = r.pending[:0]
+
+	// The old labels are gone, and with them what was blacklisted for them
+	for key := range r.blacklist {
+		if split[key.region] {
+			r.blacklist[key] = false, false
+		}
+	}
+}
+
+//Region returns the label of the region loc is in, 0 if it's water.
+func (r *Regions) Region(loc Location) int {
+	return r.label[loc]
+}
+
+//Reachable tells if there's a way over land from one square to the other.
+//A target that isn't reachable is blacklisted, so asking again from the same
+//region doesn't even need the labels.
+func (r *Regions) Reachable(from, to Location) bool {
+	if r.Blacklisted(from, to) {
+		return false
+	}
+	if r.label[from] != 0 && r.label[from] == r.label[to] {
+		return true
+	}
+	r.Blacklist(from, to)
+	return false
+}
+
+//Blacklist records that to can't be reached from the region from is in.
+func (r *Regions) Blacklist(from, to Location) {
+	r.blacklist[regionTarget{r.label[from], to}] = true
+	r.Rejected++
+}
+
+//Blacklisted tells if to has been found unreachable from the region from is
+//in. Splitting a region gives its pieces new labels, so the blacklist
+//forgets what it knew about them.
+func (r *Regions) Blacklisted(from, to Location) bool {
+	return r.blacklist[regionTarget{r.label[from], to}]
+}
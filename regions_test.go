@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRegions(t *testing.T) {
+	m := NewMap(6, 10)
+	r := NewRegions(m)
+	left, right := m.FromRowCol(2, 1), m.FromRowCol(2, 6)
+
+	//a wall with a gap in it doesn't split anything
+	for row := 0; row < 5; row++ {
+		r.AddWater(m.FromRowCol(row, 4))
+	}
+	r.Update()
+	if r.Floods != 0 || !r.Reachable(left, right) {
+		t.Errorf("gap in the wall: %d squares flooded, reachable %v", r.Floods, r.Reachable(left, right))
+	}
+
+	//the map wraps, so it takes two walls to cut it in two
+	r.AddWater(m.FromRowCol(5, 4))
+	r.Update()
+	if !r.Reachable(left, right) {
+		t.Errorf("one wall splits the map")
+	}
+	for row := 0; row < 6; row++ {
+		r.AddWater(m.FromRowCol(row, 8))
+	}
+	r.Update()
+	if r.Reachable(left, right) {
+		t.Errorf("two walls don't split the map")
+	}
+	if !r.Blacklisted(left, right) || r.Blacklisted(right, left) {
+		t.Errorf("blacklisted from the wrong side")
+	}
+	//a target is blacklisted for each region it can't be reached from
+	r.AddWater(m.FromRowCol(2, 2))
+	r.AddWater(m.FromRowCol(2, 0))
+	r.AddWater(m.FromRowCol(1, 1))
+	r.AddWater(m.FromRowCol(3, 1))
+	r.Update()
+	if r.Reachable(m.FromRowCol(0, 0), right) || r.Reachable(left, right) {
+		t.Errorf("reached across the walls")
+	}
+	if !r.Blacklisted(m.FromRowCol(0, 0), right) || !r.Blacklisted(left, right) {
+		t.Errorf("blacklisting from one region forgot the other")
+	}
+	if !r.Reachable(right, m.FromRowCol(0, 7)) {
+		t.Errorf("split the wrong way")
+	}
+	if r.Region(m.FromRowCol(0, 4)) != 0 {
+		t.Errorf("water has a region")
+	}
+}
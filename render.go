@@ -0,0 +1,121 @@
+package main
+
+import (
+	"container/list"
+	"io"
+	"os"
+)
+
+//Overlay picks the symbol to draw at a square in place of what's on the map.
+//Returning false leaves the square alone.
+type Overlay func(loc Location) (byte, bool)
+
+//Renderer writes the map as ascii, the same way Map.String does, a row at a
+//time. It can crop to a window around a square and draw overlays (targets,
+//paths, territory...) on top, later overlays winning.
+type Renderer struct {
+	m        *Map
+	cropped  bool
+	center   Location
+	radius   int
+	overlays []Overlay
+}
+
+//NewRenderer returns a renderer for the whole of m.
+func NewRenderer(m *Map) *Renderer {
+	return &Renderer{m: m}
+}
+
+//Crop limits the output to the squares at most radius rows and columns
+//away from center.
+func (r *Renderer) Crop(center Location, radius int) *Renderer {
+	r.cropped = true
+	r.center = center
+	r.radius = radius
+	return r
+}
+
+//Overlay adds o on top of what's drawn so far.
+func (r *Renderer) Overlay(o Overlay) *Renderer {
+	r.overlays = append(r.overlays, o)
+	return r
+}
+
+//WriteTo writes the map to w.
+func (r *Renderer) WriteTo(w io.Writer) (int64, os.Error) {
+	m := r.m
+	rowStart, colStart, rows, cols := 0, 0, m.Rows, m.Cols
+	if r.cropped {
+		centerRow, centerCol := m.FromLocation(r.center)
+		rowStart, colStart = centerRow-r.radius, centerCol-r.radius
+		if 2*r.radius+1 < rows {
+			rows = 2*r.radius + 1
+		}
+		if 2*r.radius+1 < cols {
+			cols = 2*r.radius + 1
+		}
+	}
+
+	written := int64(0)
+	line := make([]byte, 2*cols+1)
+	for row := rowStart; row < rowStart+rows; row++ {
+		for col := colStart; col < colStart+cols; col++ {
+			loc := m.FromRowCol(row, col)
+			symbol := m.itemGrid[loc].Symbol()
+			for _, o := range r.overlays {
+				if s, ok := o(loc); ok {
+					symbol = s
+				}
+			}
+			line[2*(col-colStart)] = symbol
+			line[2*(col-colStart)+1] = ' '
+		}
+		line[2*cols] = '\n'
+		n, err := w.Write(line)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+//MarkOverlay draws symbol on each of locs.
+func MarkOverlay(locs []Location, symbol byte) Overlay {
+	marked := make(map[Location]bool)
+	for _, loc := range locs {
+		marked[loc] = true
+	}
+	return func(loc Location) (byte, bool) {
+		return symbol, marked[loc]
+	}
+}
+
+//PathOverlay draws symbol along a path of Directions (as kept in Ant.moves)
+//starting at start.
+func PathOverlay(m *Map, start Location, path *list.List, symbol byte) Overlay {
+	locs := []Location{}
+	if path != nil {
+		loc := start
+		for e := path.Front(); e != nil; e = e.Next() {
+			loc = m.Move(loc, e.Value.(Direction))
+			locs = append(locs, loc)
+		}
+	}
+	return MarkOverlay(locs, symbol)
+}
+
+//TerritoryOverlay marks the empty squares on the edges of t: '+' on a
+//player's frontier and '=' where it's contested.
+func TerritoryOverlay(m *Map, t *Territory) Overlay {
+	return func(loc Location) (byte, bool) {
+		item := m.Item(loc)
+		if item != LAND && item != UNKNOWN {
+			return 0, false
+		}
+		if t.Owner[loc] == CONTESTED {
+			return '=', true
+		}
+		return '+', t.IsFrontier(loc)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//A scenario is a single turn for GarboAnt on a small map drawn in ascii,
+//one FromSymbol symbol per square ('.' squares become land once an ant of
+//ours can see them). The checks look at the orders the bot gave.
+type scenario struct {
+	name  string
+	board []string
+	turn  int
+	check func(r *scenarioResult)
+}
+
+type scenarioResult struct {
+	t      *testing.T
+	name   string
+	s      *State
+	ants   []Location             //our ants before the turn
+	orders map[Location]Direction //orders given, by the ant's location
+}
+
+//runScenario sets up the map the way State.Loop would and plays one turn.
+func runScenario(t *testing.T, sc scenario) *scenarioResult {
+	s := &State{
+		LoadTime:      3000,
+		TurnTime:      500,
+		Rows:          len(sc.board),
+		Cols:          len(sc.board[0]),
+		Turns:         1000,
+		ViewRadius2:   77,
+		AttackRadius2: 5,
+		SpawnRadius2:  1,
+		Turn:          sc.turn,
+	}
+	s.Map = NewMap(s.Rows, s.Cols)
+	s.Orders = NewOrderBook(s.Map)
+	out := new(bytes.Buffer)
+	s.Output = out
+
+	r := &scenarioResult{t: t, name: sc.name, s: s, orders: make(map[Location]Direction)}
+
+	//water, food and hills come before the ants in the protocol
+	for row, line := range sc.board {
+		for col := 0; col < len(line); col++ {
+			loc := s.Map.FromRowCol(row, col)
+			item := FromSymbol(line[col])
+			switch {
+			case item == WATER:
+				s.Map.AddWater(loc)
+			case item == FOOD:
+				s.Map.AddFood(loc)
+			case item.IsHill():
+				s.Map.AddHill(loc, item.ToUnoccupied())
+			}
+		}
+	}
+	for row, line := range sc.board {
+		for col := 0; col < len(line); col++ {
+			loc := s.Map.FromRowCol(row, col)
+			item := FromSymbol(line[col])
+			if !item.IsAnt() {
+				continue
+			}
+			s.Map.AddAnt(loc, item)
+			if item.ToAnt() == MY_ANT {
+				s.Map.AddDestination(loc)
+				s.Map.AddLand(loc, s.ViewRadius2)
+				r.ants = append(r.ants, loc)
+			}
+		}
+	}
+
+	bot := NewBot(s, DefaultConfig())
+	if err := bot.DoTurn(s); err != nil {
+		t.Fatalf("%s: DoTurn failed (%s)", sc.name, err)
+	}
+	for _, o := range s.Orders.Rejected {
+		row, col := s.Map.FromLocation(o.Loc)
+		t.Errorf("%s: order %d %d %s rejected (%s)", sc.name, row, col, o.Dir, o.Reason)
+	}
+	s.endTurn()
+
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line == "" || line == "go" {
+			continue
+		}
+		var row, col int
+		var dir string
+		if _, err := fmt.Sscanf(line, "o %d %d %s", &row, &col, &dir); err != nil {
+			t.Fatalf("%s: bad order %q", sc.name, line)
+		}
+		d, err := ParseDirection(dir)
+		if err != nil {
+			t.Fatalf("%s: bad order %q", sc.name, line)
+		}
+		loc := s.Map.FromRowCol(row, col)
+		if _, exists := r.orders[loc]; exists {
+			t.Errorf("%s: two orders for the ant at (%d, %d)", sc.name, row, col)
+		}
+		r.orders[loc] = d
+	}
+	return r
+}
+
+//dest returns where the ant at loc ends up.
+func (r *scenarioResult) dest(loc Location) Location {
+	dir, moved := r.orders[loc]
+	if !moved {
+		return loc
+	}
+	return r.s.Map.Move(loc, dir)
+}
+
+//distance is the number of steps between two squares, ignoring water.
+func (r *scenarioResult) distance(a, b Location) int {
+	arow, acol := r.s.Map.FromLocation(a)
+	brow, bcol := r.s.Map.FromLocation(b)
+	dr := wrapDelta(arow, brow, r.s.Rows)
+	dc := wrapDelta(acol, bcol, r.s.Cols)
+	if dr < 0 {
+		dr = -dr
+	}
+	if dc < 0 {
+		dc = -dc
+	}
+	return dr + dc
+}
+
+func (r *scenarioResult) noOrdersForOthers() {
+	for loc := range r.orders {
+		if !containsLocation(r.ants, loc) {
+			row, col := r.s.Map.FromLocation(loc)
+			r.t.Errorf("%s: order for (%d, %d) where we have no ant", r.name, row, col)
+		}
+	}
+}
+
+func (r *scenarioResult) noMovesIntoWater() {
+	for loc := range r.orders {
+		if r.s.Map.Water.Has(r.dest(loc)) {
+			row, col := r.s.Map.FromLocation(loc)
+			r.t.Errorf("%s: ant at (%d, %d) moved into water", r.name, row, col)
+		}
+	}
+}
+
+func (r *scenarioResult) noSharedDestinations() {
+	taken := make(map[Location]Location)
+	for _, loc := range r.ants {
+		dest := r.dest(loc)
+		if other, exists := taken[dest]; exists {
+			row, col := r.s.Map.FromLocation(dest)
+			r.t.Errorf("%s: ants from %v and %v both end up at (%d, %d)", r.name, other, loc, row, col)
+		}
+		taken[dest] = loc
+	}
+}
+
+func (r *scenarioResult) movesToward(row, col, targetRow, targetCol int) {
+	loc := r.s.Map.FromRowCol(row, col)
+	target := r.s.Map.FromRowCol(targetRow, targetCol)
+	if r.distance(r.dest(loc), target) >= r.distance(loc, target) {
+		r.t.Errorf("%s: ant at (%d, %d) didn't move toward (%d, %d)", r.name, row, col, targetRow, targetCol)
+	}
+}
+
+func (r *scenarioResult) moves(row, col int) {
+	if _, moved := r.orders[r.s.Map.FromRowCol(row, col)]; !moved {
+		r.t.Errorf("%s: ant at (%d, %d) didn't move", r.name, row, col)
+	}
+}
+
+//legal are the checks that hold in every scenario.
+func (r *scenarioResult) legal() {
+	r.noOrdersForOthers()
+	r.noMovesIntoWater()
+	r.noSharedDestinations()
+}
+
+var scenarios = []scenario{
+	{
+		name: "food next door",
+		board: []string{
+			"..........",
+			"..........",
+			"...a.*....",
+			"..........",
+			"..........",
+			"..........",
+			"..........",
+			"..........",
+		},
+		turn: 1,
+		check: func(r *scenarioResult) {
+			r.legal()
+			r.movesToward(2, 3, 2, 5)
+		},
+	},
+	{
+		name: "walled in",
+		board: []string{
+			"..........",
+			"...%%%....",
+			"...%a%....",
+			"..........",
+			"..........",
+			"..........",
+			"......*...",
+			"..........",
+		},
+		turn: 1,
+		check: func(r *scenarioResult) {
+			r.legal()
+			r.moves(2, 4)
+		},
+	},
+	{
+		name: "crowd around food",
+		board: []string{
+			"..........",
+			"...aaa....",
+			"...a*a....",
+			"...aaa....",
+			"..........",
+			"..........",
+			"..........",
+			"..........",
+		},
+		turn: 1,
+		check: func(r *scenarioResult) {
+			r.legal()
+		},
+	},
+	{
+		name: "enemies and hills",
+		board: []string{
+			"%%%.......",
+			"%0a....b..",
+			"%......b1.",
+			"...*......",
+			"..a.......",
+			"..........",
+			"......%%%%",
+			"..........",
+		},
+		turn: 5,
+		check: func(r *scenarioResult) {
+			r.legal()
+			r.movesToward(4, 2, 3, 3)
+		},
+	},
+	{
+		name: "outnumbering an enemy",
+		board: []string{
+			"..........",
+			"..........",
+			"..........",
+			"..a...b...",
+			"..a.......",
+			"..........",
+			"..........",
+			"..........",
+		},
+		turn: 10,
+		check: func(r *scenarioResult) {
+			r.legal()
+			r.movesToward(3, 2, 3, 6)
+			r.movesToward(4, 2, 3, 6)
+		},
+	},
+}
+
+func TestScenarios(t *testing.T) {
+	for _, sc := range scenarios {
+		sc.check(runScenario(t, sc))
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+//SkirmishSearch looks one move ahead in a small fight: it tries every joint
+//move of our ants against every joint reply of the enemy's, resolves the
+//battle the way the server does, and picks the move whose worst outcome is
+//best. All the enemies are taken to be on one side, and to know our move.
+type SkirmishSearch struct {
+	m             *Map
+	attackRadius2 int
+	lossWeight    float64 //how much worse losing an ant is than killing one
+	blocked       func(loc Location, mine bool) bool
+
+	deadline    int64 //time.Nanoseconds() the search has to stop by
+	Evaluations int
+	TimedOut    bool
+
+	//scratch for score
+	myEnemies, theirEnemies []int
+}
+
+//NewSkirmishSearch returns a search for fights on m. blocked says which
+//squares our ants (mine) or the enemy's can't move to, besides water.
+func NewSkirmishSearch(m *Map, attackRadius2 int, lossWeight float64, blocked func(loc Location, mine bool) bool) *SkirmishSearch {
+	return &SkirmishSearch{
+		m:             m,
+		attackRadius2: attackRadius2,
+		lossWeight:    lossWeight,
+		blocked:       blocked,
+	}
+}
+
+//jointMoves calls visit with every way the ants at from can move without
+//two of them ending up on the same square, staying put first, until visit
+//returns false.
+func (ss *SkirmishSearch) jointMoves(from []Location, mine bool, visit func(to []Location) bool) {
+	to := make([]Location, len(from))
+	var place func(i int) bool
+	place = func(i int) bool {
+		if i == len(from) {
+			return visit(to)
+		}
+	options:
+		for dir := NoMovement; dir >= North; dir-- {
+			dest := from[i]
+			if dir != NoMovement {
+				dest = ss.m.Move(from[i], dir)
+				if ss.m.Water.Has(dest) || ss.blocked(dest, mine) {
+					continue
+				}
+			}
+			for _, other := range to[:i] {
+				if other == dest {
+					continue options
+				}
+			}
+			to[i] = dest
+			if !place(i + 1) {
+				return false
+			}
+		}
+		return true
+	}
+	place(0)
+}
+
+//score resolves a battle between our ants and the enemy's, each ant dying
+//if an enemy in range of it has no more enemies in range than it does. Kills
+//and losses being equal, the side with more ants wants to close in and the
+//other to back off.
+func (ss *SkirmishSearch) score(mine, theirs []Location) float64 {
+	for i := range ss.myEnemies {
+		ss.myEnemies[i] = 0
+	}
+	for j := range ss.theirEnemies {
+		ss.theirEnemies[j] = 0
+	}
+	closeness := 0
+	for i, a := range mine {
+		for j, b := range theirs {
+			d := ss.m.Distance2(a, b)
+			closeness -= d
+			if d <= ss.attackRadius2 {
+				ss.myEnemies[i]++
+				ss.theirEnemies[j]++
+			}
+		}
+	}
+
+	lost, killed := 0, 0
+	for i, a := range mine {
+		for j, b := range theirs {
+			if ss.m.Distance2(a, b) <= ss.attackRadius2 && ss.theirEnemies[j] <= ss.myEnemies[i] {
+				lost++
+				break
+			}
+		}
+	}
+	for j, b := range theirs {
+		for i, a := range mine {
+			if ss.m.Distance2(a, b) <= ss.attackRadius2 && ss.myEnemies[i] <= ss.theirEnemies[j] {
+				killed++
+				break
+			}
+		}
+	}
+
+	score := float64(killed) - ss.lossWeight*float64(lost)
+	position := 0.001 * float64(closeness) / float64(len(mine)*len(theirs))
+	if len(mine) > len(theirs) {
+		return score + position
+	}
+	return score - position
+}
+
+//Best returns the squares our ants at mine should move to against the enemy
+//ants at theirs, and the worst score that can come of it. If time runs out
+//before a single move has been looked at, ok is false.
+func (ss *SkirmishSearch) Best(mine, theirs []Location, deadline int64) (dests []Location, score float64, ok bool) {
+	ss.deadline = deadline
+	ss.TimedOut = false
+	ss.myEnemies = make([]int, len(mine))
+	ss.theirEnemies = make([]int, len(theirs))
+
+	score = math.Inf(-1)
+	ss.jointMoves(mine, true, func(ours []Location) bool {
+		worst := math.Inf(1)
+		ss.jointMoves(theirs, false, func(reply []Location) bool {
+			if v := ss.score(ours, reply); v < worst {
+				worst = v
+			}
+			ss.Evaluations++
+			if ss.Evaluations%256 == 0 && time.Nanoseconds() > ss.deadline {
+				ss.TimedOut = true
+				return false
+			}
+			//once it's no better than the best so far, no need to see how bad it gets
+			return worst > score
+		})
+		if ss.TimedOut {
+			return false
+		}
+		if worst > score {
+			score = worst
+			dests = append(dests[:0], ours...)
+		}
+		return true
+	})
+	return dests, score, dests != nil
+}
+
+//direction returns the move that takes loc to dest, NoMovement if they're
+//the same square.
+func direction(m *Map, loc, dest Location) Direction {
+	for dir := North; dir <= West; dir++ {
+		if m.Move(loc, dir) == dest {
+			return dir
+		}
+	}
+	return NoMovement
+}
+
+//SkirmishBehavior takes over the ants in small fights that can break out
+//next turn, and moves them the way a SkirmishSearch says. Each fight's moves
+//are made as a whole, the ants moving out of the way first, and if that
+//can't be done the fight is searched again around the squares taken by
+//then. Bigger fights are left to AttackBehavior.
+type SkirmishBehavior struct {
+	fights  []*skirmish
+	fightOf map[*Ant]*skirmish
+}
+
+type skirmish struct {
+	ants   []*Ant
+	mine   []Location
+	theirs []Location
+	dests  []Location //where the search sends each of ants, nil if it ran out of time
+	budget int64      //nanoseconds the search had, and a search again gets
+}
+
+func (b *SkirmishBehavior) Name() string {
+	return "skirmish"
+}
+
+func (b *SkirmishBehavior) Prepare(me *GarboAnt) {
+	s := me.state
+	b.fights = b.fights[:0]
+	b.fightOf = make(map[*Ant]*skirmish)
+
+	// A fight can start next turn if both sides step into range
+	reach := math.Sqrt(float64(s.AttackRadius2)) + 2
+	reach2 := int(reach * reach)
+	fighting := make(map[*Ant]bool)
+	for _, cluster := range enemyClusters(s.Map, s.AttackRadius2) {
+		f := &skirmish{theirs: cluster}
+		for loc, ant := range me.ants {
+			if fighting[ant] {
+				continue
+			}
+			for _, enemy := range cluster {
+				if s.Map.Distance2(loc, enemy) <= reach2 {
+					f.ants = append(f.ants, ant)
+					f.mine = append(f.mine, loc)
+					break
+				}
+			}
+		}
+		if len(f.mine) == 0 || len(f.mine)+len(f.theirs) > me.config.MaxSkirmishAnts {
+			continue
+		}
+		for _, ant := range f.ants {
+			fighting[ant] = true
+		}
+		b.fights = append(b.fights, f)
+	}
+	if len(b.fights) == 0 {
+		return
+	}
+
+	// Nobody walks onto food, and our ants outside the fight stay where they are
+	blocked := func(loc Location, mine bool) bool {
+		if s.Map.Food.Has(loc) {
+			return true
+		}
+		ant := me.ants[loc]
+		return mine && ant != nil && !fighting[ant]
+	}
+	search := NewSkirmishSearch(s.Map, s.AttackRadius2, me.config.SkirmishLossWeight, blocked)
+
+	// Each fight gets an even share of what's left of the time slice
+	end := time.Nanoseconds() + int64(float64(s.TurnTime)*me.config.SkirmishTime*1e6)
+	for i, f := range b.fights {
+		now := time.Nanoseconds()
+		f.budget = (end - now) / int64(len(b.fights)-i)
+		dests, score, ok := search.Best(f.mine, f.theirs, now+f.budget)
+		if !ok {
+			s.Log.Warnf("Out of time for a %d on %d skirmish", len(f.mine), len(f.theirs))
+			continue
+		}
+		f.dests = dests
+		for _, ant := range f.ants {
+			b.fightOf[ant] = f
+		}
+		s.Log.Debugf("%d on %d skirmish scores %.2f", len(f.mine), len(f.theirs), score)
+	}
+	me.profiler.Count("skirmish_evaluations", search.Evaluations)
+}
+
+func (b *SkirmishBehavior) Bid(me *GarboAnt, ant *Ant) float64 {
+	if b.fightOf[ant] != nil {
+		return 3
+	}
+	return 0
+}
+
+func (b *SkirmishBehavior) Move(me *GarboAnt, ant *Ant) {
+	b.MoveAll(me, []*Ant{ant})
+}
+
+//MoveAll moves the ants of each fight together.
+func (b *SkirmishBehavior) MoveAll(me *GarboAnt, ants []*Ant) {
+	won := make(map[*skirmish][]*Ant)
+	for _, ant := range ants {
+		f := b.fightOf[ant]
+		won[f] = append(won[f], ant)
+		// The path the ant was on doesn't lead here any more
+		ant.moves = nil
+	}
+	for _, f := range b.fights {
+		if len(won[f]) > 0 {
+			b.commit(me, f, won[f])
+		}
+	}
+}
+
+//commit makes the moves planned for the fight f, or when some of its ants
+//went to other behaviors or the moves can't all be made, searches again
+//with the ants in ants alone, keeping off every square taken by now.
+func (b *SkirmishBehavior) commit(me *GarboAnt, f *skirmish, ants []*Ant) {
+	s := me.state
+	if len(ants) == len(f.ants) && me.moveTogether(f.mine, f.dests) {
+		return
+	}
+
+	mine := []Location{}
+	for _, ant := range ants {
+		mine = append(mine, ant.loc)
+	}
+	blocked := func(loc Location, mine bool) bool {
+		return s.Map.Food.Has(loc) || (mine && s.Map.Destinations.Has(loc))
+	}
+	search := NewSkirmishSearch(s.Map, s.AttackRadius2, me.config.SkirmishLossWeight, blocked)
+	dests, _, ok := search.Best(mine, f.theirs, time.Nanoseconds()+f.budget)
+	me.profiler.Count("skirmish_evaluations", search.Evaluations)
+	if !ok {
+		s.Log.Warnf("Out of time searching a %d on %d skirmish again", len(mine), len(f.theirs))
+		return
+	}
+	if !me.moveTogether(mine, dests) {
+		s.Log.Warnf("Can't make the moves for a %d on %d skirmish", len(mine), len(f.theirs))
+	}
+}
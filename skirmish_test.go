@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSkirmishSearch(t *testing.T) {
+	m := NewMap(20, 20)
+	open := func(loc Location, mine bool) bool { return false }
+	ss := NewSkirmishSearch(m, 5, 1.2, open)
+	deadline := time.Nanoseconds() + 1e9
+
+	//one against two backs off
+	ant := m.FromRowCol(5, 5)
+	enemies := []Location{m.FromRowCol(5, 9), m.FromRowCol(6, 9)}
+	dests, score, ok := ss.Best([]Location{ant}, enemies, deadline)
+	if !ok {
+		t.Fatalf("no move for one against two")
+	}
+	if dests[0] != m.FromRowCol(5, 4) {
+		row, col := m.FromLocation(dests[0])
+		t.Errorf("one against two went to (%d, %d), want (5, 4)", row, col)
+	}
+	if score < 0 {
+		t.Errorf("one against two can't get away (%f)", score)
+	}
+
+	//two against one doesn't lose anyone, and catches it in a dead end
+	mine := []Location{m.FromRowCol(5, 5), m.FromRowCol(6, 5)}
+	enemy := m.FromRowCol(5, 8)
+	dests, score, ok = ss.Best(mine, []Location{enemy}, deadline)
+	if !ok || score <= -1 {
+		t.Errorf("two against one scores %f", score)
+	}
+	m.AddWater(m.FromRowCol(4, 8))
+	m.AddWater(m.FromRowCol(6, 8))
+	m.AddWater(m.FromRowCol(5, 9))
+	dests, score, ok = ss.Best(mine, []Location{enemy}, deadline)
+	if !ok || score < 0.9 {
+		t.Errorf("two against one in a dead end scores %f", score)
+	}
+	for i := range mine {
+		if m.Distance2(dests[i], enemy) >= m.Distance2(mine[i], enemy) {
+			t.Errorf("ant %d didn't close in on the dead end", i)
+		}
+	}
+
+	//an ant already in range of two enemies is lost whatever it does
+	dests, score, ok = ss.Best([]Location{ant}, []Location{m.FromRowCol(4, 6), m.FromRowCol(6, 6)}, deadline)
+	if !ok || score > -1 {
+		t.Errorf("trapped ant scores %f", score)
+	}
+
+	if ss.Evaluations == 0 || ss.TimedOut {
+		t.Errorf("%d evaluations, timed out %v", ss.Evaluations, ss.TimedOut)
+	}
+}
+
+func TestJointOrder(t *testing.T) {
+	m := NewMap(10, 10)
+	ob := NewOrderBook(m)
+	add := func(row, col int) Location {
+		loc := m.FromRowCol(row, col)
+		m.AddAnt(loc, MY_ANT)
+		m.AddDestination(loc)
+		return loc
+	}
+
+	//two ants in a line shift east, the one in front has to go first
+	back, front := add(5, 5), add(5, 6)
+	from := []Location{back, front}
+	to := []Location{front, m.FromRowCol(5, 7)}
+	order, ok := jointOrder(m, from, to)
+	if !ok || len(order) != 2 || order[0] != 1 {
+		t.Fatalf("line shifts in order %v (%v)", order, ok)
+	}
+	for _, k := range order {
+		if err := ob.Add(from[k], direction(m, from[k], to[k])); err != nil {
+			t.Errorf("shifting the line: %s", err)
+		}
+	}
+
+	//ants can't swap, or move onto an ant that stays put
+	a, b := add(2, 2), add(2, 3)
+	if _, ok := jointOrder(m, []Location{a, b}, []Location{b, a}); ok {
+		t.Errorf("found an order for a swap")
+	}
+	if _, ok := jointOrder(m, []Location{a}, []Location{b}); ok {
+		t.Errorf("found an order onto an ant that isn't moving")
+	}
+	if order, ok := jointOrder(m, []Location{a, b}, []Location{a, m.FromRowCol(3, 3)}); !ok || len(order) != 1 {
+		t.Errorf("an ant staying put was given a move %v", order)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"math"
+)
+
+//centroid returns the square in the middle of locs, the short way round the
+//map.
+func centroid(m *Map, locs []Location) Location {
+	row0, col0 := m.FromLocation(locs[0])
+	sumRow, sumCol := 0, 0
+	for _, loc := range locs {
+		row, col := m.FromLocation(loc)
+		sumRow += wrapDelta(row0, row, m.Rows)
+		sumCol += wrapDelta(col0, col, m.Cols)
+	}
+	return m.FromRowCol(row0+sumRow/len(locs), col0+sumCol/len(locs))
+}
+
+//Squad is a group of ants that moves together in a line facing an enemy
+//cluster, so that as many of them as possible are in range when the fight
+//starts and none of them walks in alone.
+type Squad struct {
+	Members []*Ant
+	Anchor  Location //middle of the line
+	Target  Location //middle of the enemy cluster it's facing
+	slots   map[*Ant]Location
+}
+
+//facing returns the way from the squad's anchor to its target.
+func (sq *Squad) facing(m *Map) Direction {
+	arow, acol := m.FromLocation(sq.Anchor)
+	trow, tcol := m.FromLocation(sq.Target)
+	dr := wrapDelta(arow, trow, m.Rows)
+	dc := wrapDelta(acol, tcol, m.Cols)
+	switch {
+	case dr == 0 && dc == 0:
+		return NoMovement
+	case math.Fabs(float64(dr)) >= math.Fabs(float64(dc)) && dr < 0:
+		return North
+	case math.Fabs(float64(dr)) >= math.Fabs(float64(dc)):
+		return South
+	case dc < 0:
+		return West
+	}
+	return East
+}
+
+//Line returns the squares of a line of n ants across the facing direction,
+//centered on the anchor and filling outwards from the middle. Slots that
+//fall on water move to the nearest land square that isn't a slot already.
+func (sq *Squad) Line(m *Map, n int) []Location {
+	across := East
+	if d := sq.facing(m); d == East || d == West {
+		across = South
+	}
+	back := (across + 2) % 4
+	slots := []Location{sq.Anchor}
+	right, left := sq.Anchor, sq.Anchor
+	for len(slots) < n {
+		right = m.Move(right, across)
+		slots = append(slots, right)
+		if len(slots) < n {
+			left = m.Move(left, back)
+			slots = append(slots, left)
+		}
+	}
+
+	used := make(map[Location]bool)
+	for _, slot := range slots {
+		used[slot] = !m.Water.Has(slot)
+	}
+	for i, slot := range slots {
+		if m.Water.Has(slot) {
+			slots[i] = nearestLand(m, slot, used)
+			used[slots[i]] = true
+		}
+	}
+	return slots
+}
+
+//nearestLand returns the land square nearest loc, by steps over land and
+//water alike, that isn't in used.
+func nearestLand(m *Map, loc Location, used map[Location]bool) Location {
+	seen := map[Location]bool{loc: true}
+	queue := []Location{loc}
+	for i := 0; i < len(queue); i++ {
+		if !m.Water.Has(queue[i]) && !used[queue[i]] {
+			return queue[i]
+		}
+		for dir := North; dir <= West; dir++ {
+			if next := m.Move(queue[i], dir); !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return loc
+}
+
+//inFormation tells if every member is at most a step from its slot.
+func (sq *Squad) inFormation(m *Map) bool {
+	for ant, slot := range sq.slots {
+		if m.Distance2(ant.loc, slot) > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+//assignSlots hands out the line's slots, the nearest free member taking each
+//slot in turn from the middle outwards.
+func (sq *Squad) assignSlots(m *Map) {
+	sq.slots = make(map[*Ant]Location)
+	for _, slot := range sq.Line(m, len(sq.Members)) {
+		var best *Ant
+		bestDist := 0
+		for _, ant := range sq.Members {
+			if _, taken := sq.slots[ant]; taken {
+				continue
+			}
+			if d := m.Distance2(ant.loc, slot); best == nil || d < bestDist {
+				best, bestDist = ant, d
+			}
+		}
+		sq.slots[best] = slot
+	}
+}
+
+//SquadBehavior forms squads out of the ants near enemy clusters big enough
+//to need them, moves them in formation and disbands them when they're too
+//few or have nothing left to face.
+type SquadBehavior struct {
+	squads   []*Squad
+	memberOf map[*Ant]*Squad
+}
+
+func (b *SquadBehavior) Name() string {
+	return "squad"
+}
+
+func (b *SquadBehavior) Prepare(me *GarboAnt) {
+	s := me.state
+	reach := math.Sqrt(float64(s.AttackRadius2)) + float64(me.config.AttackReach)
+	reach2 := int(reach * reach)
+	keep2 := 4 * reach2 //squads hang on to their targets further out than they form
+	clusters := enemyClusters(s.Map, s.AttackRadius2)
+	centers := []Location{}
+	for _, cluster := range clusters {
+		centers = append(centers, centroid(s.Map, cluster))
+	}
+
+	// Keep the squads that still have the numbers and something to face
+	squads := []*Squad{}
+	b.memberOf = make(map[*Ant]*Squad)
+	for _, sq := range b.squads {
+		alive := []*Ant{}
+		for _, ant := range sq.Members {
+			if ant.seenThisTurn {
+				alive = append(alive, ant)
+			}
+		}
+		sq.Members = alive
+
+		found := false
+		for _, center := range centers {
+			if d := s.Map.Distance2(sq.Anchor, center); d <= keep2 && (!found || d < s.Map.Distance2(sq.Anchor, sq.Target)) {
+				sq.Target, found = center, true
+			}
+		}
+		if !found || len(sq.Members) < me.config.SquadMinSize {
+			row, col := s.Map.FromLocation(sq.Anchor)
+			s.Log.Infof("Squad of %d at %d %d disbanded", len(sq.Members), row, col)
+			continue
+		}
+		squads = append(squads, sq)
+		for _, ant := range sq.Members {
+			b.memberOf[ant] = sq
+		}
+	}
+
+	// Groups of enemies nobody is facing yet get a squad from the ants nearby
+	for i, cluster := range clusters {
+		if len(cluster) < 2 {
+			continue
+		}
+		faced := false
+		for _, sq := range squads {
+			faced = faced || sq.Target == centers[i]
+		}
+		if faced {
+			continue
+		}
+		recruits := []*Ant{}
+		for loc, ant := range me.ants {
+			if b.memberOf[ant] != nil || len(recruits) == me.config.SquadMaxSize {
+				continue
+			}
+			for _, enemy := range cluster {
+				if s.Map.Distance2(loc, enemy) <= reach2 {
+					recruits = append(recruits, ant)
+					break
+				}
+			}
+		}
+		if len(recruits) < me.config.SquadMinSize {
+			continue
+		}
+		locs := []Location{}
+		for _, ant := range recruits {
+			locs = append(locs, ant.loc)
+		}
+		sq := &Squad{Members: recruits, Anchor: centroid(s.Map, locs), Target: centers[i]}
+		squads = append(squads, sq)
+		for _, ant := range recruits {
+			b.memberOf[ant] = sq
+		}
+		row, col := s.Map.FromLocation(sq.Anchor)
+		s.Log.Infof("Squad of %d formed at %d %d", len(recruits), row, col)
+	}
+	b.squads = squads
+
+	// The line only moves up once everyone has caught up with it
+	for _, sq := range b.squads {
+		sq.assignSlots(s.Map)
+		if dir := sq.facing(s.Map); dir != NoMovement && sq.inFormation(s.Map) {
+			if next := s.Map.Move(sq.Anchor, dir); !s.Map.Water.Has(next) {
+				sq.Anchor = next
+				sq.assignSlots(s.Map)
+			}
+		}
+	}
+}
+
+func (b *SquadBehavior) Bid(me *GarboAnt, ant *Ant) float64 {
+	if b.memberOf[ant] != nil {
+		return 2.5
+	}
+	return 0
+}
+
+func (b *SquadBehavior) Move(me *GarboAnt, ant *Ant) {
+	b.MoveAll(me, []*Ant{ant})
+}
+
+//MoveAll steps each squad's members along the shortest way to their slots,
+//the ones moving out of the way of the others first.
+func (b *SquadBehavior) MoveAll(me *GarboAnt, ants []*Ant) {
+	s := me.state
+	won := make(map[*Squad][]*Ant)
+	for _, ant := range ants {
+		sq := b.memberOf[ant]
+		won[sq] = append(won[sq], ant)
+		// The path the ant was on doesn't lead here any more
+		ant.moves = nil
+	}
+	for _, sq := range b.squads {
+		from, to := []Location{}, []Location{}
+		for _, ant := range won[sq] {
+			from = append(from, ant.loc)
+			to = append(to, s.Map.Move(ant.loc, me.fightStep(ant, sq.slots[ant])))
+		}
+		order, _ := jointOrder(s.Map, from, to)
+		for _, k := range order {
+			me.safeMove(from[k], direction(s.Map, from[k], to[k]))
+		}
+	}
+}
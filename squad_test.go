@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSquad(t *testing.T) {
+	m := NewMap(20, 20)
+	if c := centroid(m, []Location{m.FromRowCol(0, 19), m.FromRowCol(0, 1)}); c != m.FromRowCol(0, 0) {
+		t.Errorf("centroid across the edge is %v", c)
+	}
+
+	sq := &Squad{Anchor: m.FromRowCol(5, 5), Target: m.FromRowCol(6, 12)}
+	if d := sq.facing(m); d != East {
+		t.Errorf("facing %s, want e", d)
+	}
+	line := sq.Line(m, 3)
+	want := []Location{m.FromRowCol(5, 5), m.FromRowCol(6, 5), m.FromRowCol(4, 5)}
+	for i := range want {
+		if line[i] != want[i] {
+			t.Errorf("slot %d is %v, want %v", i, line[i], want[i])
+		}
+	}
+
+	//members fill the slots nearest them, and are in formation once there
+	a, b, c := &Ant{loc: m.FromRowCol(3, 4)}, &Ant{loc: m.FromRowCol(5, 3)}, &Ant{loc: m.FromRowCol(7, 4)}
+	sq.Members = []*Ant{a, b, c}
+	sq.assignSlots(m)
+	if sq.slots[b] != want[0] || sq.slots[c] != want[1] || sq.slots[a] != want[2] {
+		t.Errorf("slots handed out wrong: %v", sq.slots)
+	}
+	if sq.inFormation(m) {
+		t.Errorf("in formation before anyone got to their slot")
+	}
+	for ant, slot := range sq.slots {
+		ant.loc = slot
+	}
+	if !sq.inFormation(m) {
+		t.Errorf("not in formation with everyone in their slot")
+	}
+
+	//slots that fall on water go to the nearest land instead
+	m.AddWater(m.FromRowCol(6, 5))
+	line = sq.Line(m, 3)
+	for i, slot := range line {
+		if m.Water.Has(slot) {
+			t.Errorf("slot %d is on water", i)
+		}
+		for _, other := range line[:i] {
+			if other == slot {
+				t.Errorf("slot %d is taken twice", i)
+			}
+		}
+	}
+	if line[0] != want[0] || line[2] != want[2] || m.Distance2(line[1], want[1]) != 1 {
+		t.Errorf("water slot moved to %v", line[1])
+	}
+}
+
+func TestSquadMoves(t *testing.T) {
+	me := testBot(20, 20)
+	m := me.state.Map
+
+	//two members in a line shift east, the back one onto the front one's square
+	sq := &Squad{slots: make(map[*Ant]Location)}
+	for _, col := range []int{5, 6} {
+		ant := me.addAnt(5, col)
+		sq.Members = append(sq.Members, ant)
+		sq.slots[ant] = m.FromRowCol(5, col+1)
+	}
+	b := &SquadBehavior{squads: []*Squad{sq}, memberOf: make(map[*Ant]*Squad)}
+	for _, ant := range sq.Members {
+		b.memberOf[ant] = sq
+	}
+	b.MoveAll(me, sq.Members)
+	orders := me.state.Orders
+	if len(orders.Rejected) != 0 || len(orders.Orders()) != 2 {
+		t.Errorf("%d orders given, rejected %v", len(orders.Orders()), orders.Rejected)
+	}
+	for _, ant := range sq.Members {
+		if ant.target != sq.slots[ant] {
+			t.Errorf("ant at %v headed for %v, want %v", ant.loc, ant.target, sq.slots[ant])
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"sort"
+)
+
+//Behavior is one independent piece of strategy (gathering food, exploring,
+//fighting...). Every turn each behavior bids for the ants it wants, and moves
+//the ones it wins.
+type Behavior interface {
+	//Name identifies the behavior so it can be switched on and off
+	Name() string
+	//Prepare is called once a turn, before any bidding
+	Prepare(me *GarboAnt)
+	//Bid returns how badly the behavior wants to control ant, 0 or less
+	//means it doesn't want it at all
+	Bid(me *GarboAnt, ant *Ant) float64
+	//Move issues the order for an ant the behavior won
+	Move(me *GarboAnt, ant *Ant)
+}
+
+//GroupMover is a Behavior whose ants have to be moved together, because one
+//ant's move is only free once another has made its own. The strategy hands
+//it all the ants it won at once instead of calling Move for each.
+type GroupMover interface {
+	//MoveAll issues the orders for all the ants the behavior won
+	MoveAll(me *GarboAnt, ants []*Ant)
+}
+
+type registeredBehavior struct {
+	behavior Behavior
+	priority int
+	enabled  bool
+	weight   float64 //every bid the behavior makes is multiplied by this
+}
+
+type byPriority []*registeredBehavior
+
+func (b byPriority) Len() int           { return len(b) }
+func (b byPriority) Less(i, j int) bool { return b[i].priority > b[j].priority }
+func (b byPriority) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+//Strategy runs the registered behaviors in order of priority. Each ant goes
+//to the behavior with the highest weighted bid, ties going to the higher
+//priority, and behaviors move their ants highest priority first so they get
+//the first pick of destinations.
+type Strategy struct {
+	behaviors []*registeredBehavior
+}
+
+//NewStrategy returns a strategy with no behaviors.
+func NewStrategy() *Strategy {
+	return &Strategy{}
+}
+
+//Register adds b to the strategy, enabled and with a weight of 1.
+func (st *Strategy) Register(b Behavior, priority int) {
+	st.behaviors = append(st.behaviors, &registeredBehavior{b, priority, true, 1})
+	sort.Sort(byPriority(st.behaviors))
+}
+
+//Enable switches the named behavior on or off, returning false if there is
+//no such behavior.
+func (st *Strategy) Enable(name string, enabled bool) bool {
+	for _, rb := range st.behaviors {
+		if rb.behavior.Name() == name {
+			rb.enabled = enabled
+			return true
+		}
+	}
+	return false
+}
+
+//SetWeight sets what the named behavior's bids are multiplied by, returning
+//false if there is no such behavior.
+func (st *Strategy) SetWeight(name string, weight float64) bool {
+	for _, rb := range st.behaviors {
+		if rb.behavior.Name() == name {
+			rb.weight = weight
+			return true
+		}
+	}
+	return false
+}
+
+//Weight returns what the named behavior's bids are multiplied by, 0 if there
+//is no such behavior.
+func (st *Strategy) Weight(name string) float64 {
+	for _, rb := range st.behaviors {
+		if rb.behavior.Name() == name {
+			return rb.weight
+		}
+	}
+	return 0
+}
+
+//Behavior returns the registered behavior with the given name, or nil.
+func (st *Strategy) Behavior(name string) Behavior {
+	for _, rb := range st.behaviors {
+		if rb.behavior.Name() == name {
+			return rb.behavior
+		}
+	}
+	return nil
+}
+
+//Behaviors returns the names of the registered behaviors, highest priority
+//first.
+func (st *Strategy) Behaviors() []string {
+	names := []string{}
+	for _, rb := range st.behaviors {
+		names = append(names, rb.behavior.Name())
+	}
+	return names
+}
+
+//Run prepares every enabled behavior, hands out the ants and has each
+//behavior move its share. Each step is profiled as its own phase, e.g. the
+//food search is "food.prepare" and the hunt "food.move".
+func (st *Strategy) Run(me *GarboAnt) {
+	active := []*registeredBehavior{}
+	for _, rb := range st.behaviors {
+		if rb.enabled {
+			me.profiler.Begin(rb.behavior.Name() + ".prepare")
+			rb.behavior.Prepare(me)
+			active = append(active, rb)
+		}
+	}
+
+	me.profiler.Begin("bid")
+	won := make([][]*Ant, len(active))
+	for _, ant := range me.ants {
+		best := -1
+		bestBid := 0.0
+		for i, rb := range active {
+			bid := rb.weight * rb.behavior.Bid(me, ant)
+			if bid > bestBid {
+				best = i
+				bestBid = bid
+			}
+		}
+		if best >= 0 {
+			won[best] = append(won[best], ant)
+		}
+	}
+
+	for i, rb := range active {
+		me.profiler.Begin(rb.behavior.Name() + ".move")
+		if gm, ok := rb.behavior.(GroupMover); ok {
+			gm.MoveAll(me, won[i])
+			continue
+		}
+		for _, ant := range won[i] {
+			rb.behavior.Move(me, ant)
+		}
+	}
+	me.profiler.End()
+}
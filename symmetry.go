@@ -0,0 +1,311 @@
+package main
+
+//Ants maps are generated symmetric, so every enemy hill is an image of one of
+//ours under the map's symmetry. Symmetry keeps a list of candidate transforms
+//and throws out every one that contradicts the terrain we have seen so far;
+//the survivors are used to predict hills and water we haven't seen yet.
+
+//SymmetryKind enumerates the transforms the map generator uses
+type SymmetryKind int8
+
+const (
+	SYM_TRANSLATE  SymmetryKind = iota //(row, col) -> (row + a, col + b)
+	SYM_MIRROR_ROW                     //(row, col) -> (a - row, col)
+	SYM_MIRROR_COL                     //(row, col) -> (row, b - col)
+	SYM_ROTATE_180                     //(row, col) -> (a - row, b - col)
+)
+
+type symCandidate struct {
+	kind SymmetryKind
+	a, b int
+
+	support int
+}
+
+//apply returns the image of loc under the transform
+func (c *symCandidate) apply(m *Map, loc Location) Location {
+	row, col := m.FromLocation(loc)
+	switch c.kind {
+	case SYM_TRANSLATE:
+		return m.FromRowCol(row+c.a, col+c.b)
+	case SYM_MIRROR_ROW:
+		return m.FromRowCol(c.a-row, col)
+	case SYM_MIRROR_COL:
+		return m.FromRowCol(row, c.b-col)
+	}
+	return m.FromRowCol(c.a-row, c.b-col)
+}
+
+//invert returns the location whose image is loc
+func (c *symCandidate) invert(m *Map, loc Location) Location {
+	if c.kind != SYM_TRANSLATE {
+		return c.apply(m, loc) //the others are their own inverse
+	}
+	row, col := m.FromLocation(loc)
+	return m.FromRowCol(row-c.a, col-c.b)
+}
+
+//orbit returns the images of loc under repeated application, excluding loc.
+func (c *symCandidate) orbit(m *Map, loc Location) []Location {
+	images := []Location{}
+	for next := c.apply(m, loc); next != loc && len(images) < 10; next = c.apply(m, next) {
+		images = append(images, next)
+	}
+	return images
+}
+
+//Symmetry infers the symmetry of the map from what we have seen of it.
+type Symmetry struct {
+	m *Map
+
+	known      []Item //persistent terrain, one of UNKNOWN, WATER or LAND
+	ownHills   map[Location]bool
+	enemyHills map[Location]bool
+	pending    []Location //squares learned but not yet checked against the candidates
+
+	candidates []*symCandidate
+
+	budget      int //square checks per call to Update, the rest waits for the next turn
+	hillSupport int //terrain support a hill sighting is worth
+}
+
+//NewSymmetry returns a symmetry detector for m with no knowledge yet.
+func NewSymmetry(m *Map, budget, hillSupport int) *Symmetry {
+	sym := &Symmetry{
+		m:           m,
+		known:       make([]Item, m.Rows*m.Cols),
+		ownHills:    make(map[Location]bool),
+		enemyHills:  make(map[Location]bool),
+		budget:      budget,
+		hillSupport: hillSupport,
+	}
+	for i := range sym.known {
+		sym.known[i] = UNKNOWN
+	}
+	return sym
+}
+
+//periods returns the offsets along an axis of the given size that repeat
+//after between 2 and 10 steps, which is all a translation can use.
+func periods(size int) []int {
+	offsets := []int{}
+	for d := 0; d < size; d++ {
+		for n := 2; n <= 10; n++ {
+			if (d*n)%size == 0 {
+				offsets = append(offsets, d)
+				break
+			}
+		}
+	}
+	return offsets
+}
+
+//makeCandidates enumerates every transform that maps none of our hills onto
+//itself or onto another of our hills.
+func (sym *Symmetry) makeCandidates() {
+	m := sym.m
+	all := []*symCandidate{}
+	rowOffsets := append([]int{0}, periods(m.Rows)...)
+	colOffsets := append([]int{0}, periods(m.Cols)...)
+	for _, a := range rowOffsets {
+		for _, b := range colOffsets {
+			if a != 0 || b != 0 {
+				all = append(all, &symCandidate{kind: SYM_TRANSLATE, a: a, b: b})
+			}
+		}
+	}
+	for a := 0; a < m.Rows; a++ {
+		all = append(all, &symCandidate{kind: SYM_MIRROR_ROW, a: a})
+	}
+	for b := 0; b < m.Cols; b++ {
+		all = append(all, &symCandidate{kind: SYM_MIRROR_COL, b: b})
+	}
+	for a := 0; a < m.Rows; a++ {
+		for b := 0; b < m.Cols; b++ {
+			all = append(all, &symCandidate{kind: SYM_ROTATE_180, a: a, b: b})
+		}
+	}
+
+	sym.candidates = all[:0]
+	for _, c := range all {
+		valid := true
+		for hill := range sym.ownHills {
+			image := c.apply(m, hill)
+			if sym.ownHills[image] || sym.known[image] == WATER {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			sym.candidates = append(sym.candidates, c)
+		}
+	}
+	//the enemy hills seen so far count for the new list too
+	for enemy := range sym.enemyHills {
+		sym.supportHill(enemy)
+	}
+}
+
+//supportHill adds hillSupport to every candidate that maps one of our hills
+//onto the enemy hill at loc.
+func (sym *Symmetry) supportHill(loc Location) {
+	for _, c := range sym.candidates {
+		for own := range sym.ownHills {
+			for _, image := range c.orbit(sym.m, own) {
+				if image == loc {
+					c.support += sym.hillSupport
+				}
+			}
+		}
+	}
+}
+
+//consistent checks the pair (loc, image) and reports whether c survives it.
+func (sym *Symmetry) consistent(c *symCandidate, loc, image Location) bool {
+	seen := sym.known[image]
+	if seen == UNKNOWN {
+		return true
+	}
+	if seen != sym.known[loc] {
+		return false
+	}
+	c.support++
+	return true
+}
+
+//Update records what is visible on the map this turn and eliminates the
+//candidates it contradicts.
+func (sym *Symmetry) Update(m *Map) {
+	for i, item := range m.itemGrid {
+		loc := Location(i)
+		if item == UNKNOWN || sym.known[loc] != UNKNOWN {
+			continue
+		}
+		if item == WATER {
+			sym.known[loc] = WATER
+		} else {
+			sym.known[loc] = LAND
+		}
+		sym.pending = append(sym.pending, loc)
+	}
+
+	newHill := false
+	for _, loc := range m.Hills.Locations() {
+		hill := m.Hills.At(loc)
+		if hill == MY_HILL && !sym.ownHills[loc] {
+			sym.ownHills[loc] = true
+			newHill = true
+		} else if hill.IsEnemyHill() && !sym.enemyHills[loc] {
+			sym.enemyHills[loc] = true
+			if !newHill {
+				//a new list gets the support when it's made
+				sym.supportHill(loc)
+			}
+		}
+	}
+	if len(sym.ownHills) == 0 {
+		return
+	}
+	if sym.candidates == nil || newHill {
+		sym.makeCandidates()
+		//everything known so far has to be checked against the new list
+		sym.pending = sym.pending[:0]
+		for i, item := range sym.known {
+			if item != UNKNOWN {
+				sym.pending = append(sym.pending, Location(i))
+			}
+		}
+	}
+
+	work := 0
+	for len(sym.pending) > 0 && work < sym.budget {
+		loc := sym.pending[len(sym.pending)-1]
+		sym.pending = sym.pending[:len(sym.pending)-1]
+
+		alive := sym.candidates[:0]
+		for _, c := range sym.candidates {
+			ok := sym.consistent(c, loc, c.apply(m, loc))
+			if ok && c.kind == SYM_TRANSLATE {
+				ok = sym.consistent(c, loc, c.invert(m, loc))
+			}
+			if ok {
+				alive = append(alive, c)
+			}
+		}
+		work += len(sym.candidates)
+		sym.candidates = alive
+	}
+}
+
+//Candidates returns the number of transforms still consistent with the map.
+func (sym *Symmetry) Candidates() int {
+	return len(sym.candidates)
+}
+
+//totalWeight is what the confidence of a prediction is measured against.
+func (sym *Symmetry) totalWeight() float32 {
+	total := float32(0)
+	for _, c := range sym.candidates {
+		total += float32(c.support + 1)
+	}
+	return total
+}
+
+//PredictHills returns the likely enemy hill locations along with the
+//fraction (0 - 1) of the evidence that supports each of them.
+func (sym *Symmetry) PredictHills() map[Location]float32 {
+	predicted := make(map[Location]float32)
+	total := sym.totalWeight()
+	if total == 0 {
+		return predicted
+	}
+	for _, c := range sym.candidates {
+		weight := float32(c.support+1) / total
+		images := []Location{}
+		for own := range sym.ownHills {
+			for _, image := range c.orbit(sym.m, own) {
+				if !sym.ownHills[image] && !containsLocation(images, image) {
+					images = append(images, image)
+					predicted[image] += weight
+				}
+			}
+		}
+	}
+	return predicted
+}
+
+func containsLocation(locs []Location, loc Location) bool {
+	for _, l := range locs {
+		if l == loc {
+			return true
+		}
+	}
+	return false
+}
+
+//PredictItem returns WATER or LAND for loc, as seen at its images under the
+//surviving transforms, along with the confidence in that answer. UNKNOWN is
+//returned if no transform maps loc onto anything we've seen.
+func (sym *Symmetry) PredictItem(loc Location) (Item, float32) {
+	if sym.known[loc] != UNKNOWN {
+		return sym.known[loc], 1
+	}
+	water, land := float32(0), float32(0)
+	for _, c := range sym.candidates {
+		weight := float32(c.support + 1)
+		switch sym.known[c.invert(sym.m, loc)] {
+		case WATER:
+			water += weight
+		case LAND:
+			land += weight
+		}
+	}
+	total := sym.totalWeight()
+	if water == 0 && land == 0 {
+		return UNKNOWN, 0
+	}
+	if water > land {
+		return WATER, water / total
+	}
+	return LAND, land / total
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSymmetry(t *testing.T) {
+	m := NewMap(8, 8)
+	water := [][2]int{{0, 3}, {2, 5}, {3, 0}, {1, 4}}
+	for _, w := range water {
+		//rotated 180 degrees around (3.5, 3.5)
+		m.AddWater(m.FromRowCol(w[0], w[1]))
+		m.AddWater(m.FromRowCol(7-w[0], 7-w[1]))
+	}
+	hidden := m.FromRowCol(5, 2)
+	for i := range m.itemGrid {
+		if m.itemGrid[i] == UNKNOWN {
+			m.itemGrid[i] = LAND
+		}
+	}
+	m.itemGrid[hidden] = UNKNOWN
+	m.AddHill(m.FromRowCol(1, 1), MY_HILL)
+
+	c := DefaultConfig()
+	sym := NewSymmetry(m, c.SymmetryBudget, c.SymmetryHillSupport)
+	sym.Update(m)
+	if sym.Candidates() == 0 {
+		t.Fatalf("eliminated the real symmetry")
+	}
+
+	enemy := m.FromRowCol(6, 6)
+	hills := sym.PredictHills()
+	for loc, confidence := range hills {
+		if loc != enemy && confidence >= hills[enemy] {
+			t.Errorf("predicted %v over the real hill (%v >= %v)", loc, confidence, hills[enemy])
+		}
+	}
+
+	item, confidence := sym.PredictItem(hidden)
+	if item != WATER || confidence < 0.5 {
+		t.Errorf("hidden square predicted as %c with %v confidence, wanted water", item.Symbol(), confidence)
+	}
+}
+
+func TestSymmetryHillSupport(t *testing.T) {
+	m := NewMap(8, 8)
+	c := DefaultConfig()
+	sym := NewSymmetry(m, c.SymmetryBudget, c.SymmetryHillSupport)
+
+	//the enemy hill is seen before any of ours
+	enemy := m.FromRowCol(6, 6)
+	m.AddHill(enemy, MY_HILL+1)
+	sym.Update(m)
+	m.AddHill(m.FromRowCol(1, 1), MY_HILL)
+	sym.Update(m)
+
+	supported := 0
+	for _, cand := range sym.candidates {
+		if cand.support >= c.SymmetryHillSupport && containsLocation(cand.orbit(m, m.FromRowCol(1, 1)), enemy) {
+			supported++
+		}
+	}
+	if supported == 0 {
+		t.Errorf("no candidate got the support of the enemy hill")
+	}
+	if hills := sym.PredictHills(); hills[enemy] < 0.5 {
+		t.Errorf("enemy hill predicted with %v confidence", hills[enemy])
+	}
+}
+
+func TestPredictedHillsOutOfSight(t *testing.T) {
+	me := testBot(8, 8)
+	m := me.state.Map
+	c := me.config
+	me.symmetry = NewSymmetry(m, c.SymmetryBudget, c.SymmetryHillSupport)
+	own, enemy := m.FromRowCol(1, 1), m.FromRowCol(6, 6)
+
+	m.AddHill(own, MY_HILL)
+	m.AddHill(enemy, HILL_1)
+	m.AddLand(enemy, 2)
+	me.symmetry.Update(m)
+	me.updateHills()
+	me.predictHills()
+	if _, predicted := me.predictedHills[enemy]; !predicted {
+		t.Fatalf("the hill in sight isn't predicted")
+	}
+
+	//a hill we saw is still predicted once it's out of sight
+	m.Reset()
+	m.AddHill(own, MY_HILL)
+	me.symmetry.Update(m)
+	me.updateHills()
+	me.predictHills()
+	if _, predicted := me.predictedHills[enemy]; !predicted {
+		t.Errorf("dropped the prediction of a hill out of sight")
+	}
+
+	//but not once its square is in sight with no hill on it
+	m.Reset()
+	m.AddLand(enemy, 2)
+	me.symmetry.Update(m)
+	me.updateHills()
+	me.predictHills()
+	if _, predicted := me.predictedHills[enemy]; predicted {
+		t.Errorf("predicted a hill on a square in sight without one")
+	}
+}
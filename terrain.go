@@ -0,0 +1,333 @@
+package main
+
+//Terrain is what the shape of the land says about a square: how far it is
+//from water, how wide the passage it's in is, whether it's a chokepoint
+//every path from one side to the other goes through, and whether it's in a
+//dead end, cut off by a chokepoint with no hill and nothing unexplored
+//beyond it. Everything not known to be water counts as land. It's all worked
+//out again whenever new water turns up, and the dead ends whenever land is
+//seen or a hill found for the first time.
+type Terrain struct {
+	m             *Map
+	corridorWidth int //passages this wide or narrower are corridors
+
+	Clearance   []int  //steps to the nearest water, at most corridorWidth+1
+	Width       []int  //width of the passage each square is in, at most 2*corridorWidth+1
+	Chokepoint  []bool //land that would be cut in two without the square
+	DeadEnd     []bool //land off a chokepoint with nothing beyond worth going to
+	Chokepoints []Location
+	Rebuilds    int //times new water had everything worked out again
+
+	water []bool
+	seen  []bool
+	hill  []bool
+	dirty bool //new water
+	stale bool //new land seen or hills found
+
+	//scratch
+	queue     []Location
+	ridge     []int
+	disc, low []int32
+	size      []int32 //squares below each one in the search tree
+	parent    []Location
+	order     []Location //land in the order the search found it
+	sides     []cutSide
+	open      []int32 //squares worth going to among the first i of order
+	cover     []int32
+}
+
+//cutSide is land a chokepoint cuts off from the rest of its region. Both
+//are ranges of Terrain.order, the region's with the side in it.
+type cutSide struct {
+	mouth               Location
+	first, size         int32
+	regionFirst, region int32
+}
+
+//NewTerrain returns the terrain of m with no water known. Passages up to
+//corridorWidth squares wide count as corridors.
+func NewTerrain(m *Map, corridorWidth int) *Terrain {
+	size := m.Rows * m.Cols
+	return &Terrain{
+		m:             m,
+		corridorWidth: corridorWidth,
+		Clearance:     make([]int, size),
+		Width:         make([]int, size),
+		Chokepoint:    make([]bool, size),
+		DeadEnd:       make([]bool, size),
+		water:         make([]bool, size),
+		seen:          make([]bool, size),
+		hill:          make([]bool, size),
+		ridge:         make([]int, size),
+		disc:          make([]int32, size),
+		low:           make([]int32, size),
+		size:          make([]int32, size),
+		parent:        make([]Location, size),
+		open:          make([]int32, size+1),
+		cover:         make([]int32, size+1),
+		dirty:         true,
+	}
+}
+
+//AddWater marks loc as water, to be taken into account by the next Update.
+func (t *Terrain) AddWater(loc Location) {
+	if !t.water[loc] {
+		t.water[loc] = true
+		t.dirty = true
+	}
+}
+
+//See marks loc as seen, so it's no longer unexplored land.
+func (t *Terrain) See(loc Location) {
+	if !t.seen[loc] {
+		t.seen[loc] = true
+		t.stale = true
+	}
+}
+
+//AddHill marks loc as a hill, which keeps the land around it from being a
+//dead end even once it's been razed.
+func (t *Terrain) AddHill(loc Location) {
+	if !t.hill[loc] {
+		t.hill[loc] = true
+		t.stale = true
+	}
+}
+
+//Corridor tells if loc is in a passage no wider than the corridor width.
+func (t *Terrain) Corridor(loc Location) bool {
+	return !t.water[loc] && t.Width[loc] <= t.corridorWidth
+}
+
+//Update works the terrain out again if any water has turned up since the
+//last update, and the dead ends if anything's been seen.
+func (t *Terrain) Update() {
+	if t.dirty {
+		t.dirty = false
+		t.stale = true
+		t.Rebuilds++
+		t.clearance()
+		t.widths()
+		t.chokepoints()
+	}
+	if t.stale {
+		t.stale = false
+		t.deadEnds()
+	}
+}
+
+//clearance does a BFS out from all the water at once.
+func (t *Terrain) clearance() {
+	limit := t.corridorWidth + 1
+	t.queue = t.queue[:0]
+	for i := range t.Clearance {
+		t.Clearance[i] = limit
+		if t.water[i] {
+			t.Clearance[i] = 0
+			t.queue = append(t.queue, Location(i))
+		}
+	}
+	for i := 0; i < len(t.queue); i++ {
+		loc := t.queue[i]
+		for dir := North; dir <= West; dir++ {
+			next := t.m.Move(loc, dir)
+			if d := t.Clearance[loc] + 1; d < t.Clearance[next] {
+				t.Clearance[next] = d
+				t.queue = append(t.queue, next)
+			}
+		}
+	}
+}
+
+//widths takes the width of a passage to be twice the clearance down its
+//middle, less one, the middle being the furthest from water any square
+//within corridorWidth of loc gets. Passages an even number of squares wide
+//come out a square narrower than they are.
+func (t *Terrain) widths() {
+	m, k := t.m, t.corridorWidth
+
+	// The window maximum is done a row at a time and then a column at a time
+	for row := 0; row < m.Rows; row++ {
+		for col := 0; col < m.Cols; col++ {
+			best := 0
+			for d := -k; d <= k; d++ {
+				if c := t.Clearance[m.FromRowCol(row, col+d)]; c > best {
+					best = c
+				}
+			}
+			t.ridge[m.FromRowCol(row, col)] = best
+		}
+	}
+	for row := 0; row < m.Rows; row++ {
+		for col := 0; col < m.Cols; col++ {
+			best := 0
+			for d := -k; d <= k; d++ {
+				if c := t.ridge[m.FromRowCol(row+d, col)]; c > best {
+					best = c
+				}
+			}
+			t.Width[m.FromRowCol(row, col)] = 2*best - 1
+		}
+	}
+}
+
+//chokepoints finds the articulation points of the land with Tarjan's
+//algorithm, iteratively since a maze can make the search very deep, and the
+//sides each of them cuts off. Squares are numbered in the order the search
+//finds them, so the squares below one in the search tree are a range of
+//order.
+func (t *Terrain) chokepoints() {
+	type frame struct {
+		loc      Location
+		dir      Direction
+		children int
+	}
+	for i := range t.disc {
+		t.disc[i] = 0
+		t.Chokepoint[i] = false
+	}
+	t.Chokepoints = t.Chokepoints[:0]
+	t.order = t.order[:0]
+	t.sides = t.sides[:0]
+
+	visits := int32(0)
+	stack := []frame{}
+	rootSides := []cutSide{}
+	for i := range t.disc {
+		root := Location(i)
+		if t.water[root] || t.disc[root] != 0 {
+			continue
+		}
+		regionFirst, regionSides := visits, len(t.sides)
+		rootSides = rootSides[:0]
+		visits++
+		t.disc[root], t.low[root], t.parent[root], t.size[root] = visits, visits, -1, 1
+		t.order = append(t.order, root)
+		stack = append(stack[:0], frame{root, North, 0})
+		for len(stack) > 0 {
+			f := &stack[len(stack)-1]
+			if f.dir <= West {
+				next := t.m.Move(f.loc, f.dir)
+				f.dir++
+				switch {
+				case t.water[next]:
+				case t.disc[next] == 0:
+					visits++
+					t.disc[next], t.low[next], t.parent[next], t.size[next] = visits, visits, f.loc, 1
+					t.order = append(t.order, next)
+					f.children++
+					stack = append(stack, frame{next, North, 0})
+				case next != t.parent[f.loc] && t.disc[next] < t.low[f.loc]:
+					t.low[f.loc] = t.disc[next]
+				}
+				continue
+			}
+
+			// Done with this square, pass what it can reach back to its parent
+			done := *f
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				if done.children > 1 {
+					t.Chokepoint[done.loc] = true
+					t.sides = append(t.sides, rootSides...)
+				}
+				continue
+			}
+			up := &stack[len(stack)-1]
+			if t.low[done.loc] < t.low[up.loc] {
+				t.low[up.loc] = t.low[done.loc]
+			}
+			t.size[up.loc] += t.size[done.loc]
+			side := cutSide{mouth: up.loc, first: t.disc[done.loc] - 1, size: t.size[done.loc]}
+			switch {
+			case t.parent[up.loc] == -1:
+				rootSides = append(rootSides, side)
+			case t.low[done.loc] >= t.disc[up.loc]:
+				t.Chokepoint[up.loc] = true
+				t.sides = append(t.sides, side)
+			}
+		}
+		for k := regionSides; k < len(t.sides); k++ {
+			t.sides[k].regionFirst, t.sides[k].region = regionFirst, visits-regionFirst
+		}
+	}
+	for i, choke := range t.Chokepoint {
+		if choke {
+			t.Chokepoints = append(t.Chokepoints, Location(i))
+		}
+	}
+}
+
+//deadEnds marks the land chokepoints cut off that has no hill and no
+//unexplored land in it: the sides below them in the search tree, and what's
+//left of the region once those are cut away. Counts of the squares worth
+//going to along order tell if a part has any, and the parts are marked as
+//ranges of it.
+func (t *Terrain) deadEnds() {
+	for i := range t.DeadEnd {
+		t.DeadEnd[i] = false
+	}
+	t.open = append(t.open[:0], 0)
+	for i, loc := range t.order {
+		t.open = append(t.open, t.open[i])
+		if !t.seen[loc] || t.hill[loc] {
+			t.open[i+1]++
+		}
+	}
+	openIn := func(first, size int32) int32 {
+		return t.open[first+size] - t.open[first]
+	}
+	cover := t.cover[:len(t.order)+1]
+	for i := range cover {
+		cover[i] = 0
+	}
+
+	// The sides below each chokepoint
+	cut := t.low //done with it once the chokepoints are found
+	for _, side := range t.sides {
+		cut[side.mouth] = 0
+	}
+	for _, side := range t.sides {
+		open := openIn(side.first, side.size)
+		cut[side.mouth] += open
+		if open == 0 {
+			cover[side.first]++
+			cover[side.first+side.size]--
+		}
+	}
+
+	// The rest of the region, less the chokepoint and the sides below it
+	for _, side := range t.sides {
+		mouth := t.disc[side.mouth] - 1
+		if cut[side.mouth] >= 0 {
+			if openIn(side.regionFirst, side.region)-openIn(mouth, 1)-cut[side.mouth] != 0 {
+				continue
+			}
+			cut[side.mouth] = -1
+			cover[side.regionFirst]++
+			cover[side.regionFirst+side.region]--
+			cover[mouth]--
+			cover[mouth+1]++
+		}
+		cover[side.first]--
+		cover[side.first+side.size]++
+	}
+
+	covered := int32(0)
+	for i, loc := range t.order {
+		covered += cover[i]
+		t.DeadEnd[loc] = covered > 0
+	}
+}
+
+//CorridorLayer makes squares in corridors cost weight more to step onto, so
+//paths keep to open ground, where we can fight on a wide front, when there's
+//a way round.
+func CorridorLayer(t *Terrain, weight float64) CostLayer {
+	return func(loc Location) float64 {
+		if t.Corridor(loc) {
+			return weight
+		}
+		return 0
+	}
+}
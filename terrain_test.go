@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTerrain(t *testing.T) {
+	//a corridor three wide above an open stretch five wide
+	m := NewMap(10, 10)
+	tr := NewTerrain(m, 3)
+	for col := 0; col < 10; col++ {
+		tr.AddWater(m.FromRowCol(0, col))
+		tr.AddWater(m.FromRowCol(4, col))
+	}
+	tr.Update()
+	if c := tr.Clearance[m.FromRowCol(2, 5)]; c != 2 {
+		t.Errorf("clearance %d in the middle of the corridor", c)
+	}
+	if w := tr.Width[m.FromRowCol(1, 5)]; w != 3 || !tr.Corridor(m.FromRowCol(1, 5)) {
+		t.Errorf("corridor is %d wide", w)
+	}
+	if w := tr.Width[m.FromRowCol(6, 5)]; w != 5 || tr.Corridor(m.FromRowCol(6, 5)) {
+		t.Errorf("open stretch is %d wide", w)
+	}
+	if len(tr.Chokepoints) != 0 {
+		t.Errorf("chokepoints in open corridors: %v", tr.Chokepoints)
+	}
+
+	//two rooms joined by a gap, with pockets off the left one
+	m = NewMap(7, 12)
+	tr = NewTerrain(m, 3)
+	for col := 0; col < 12; col++ {
+		tr.AddWater(m.FromRowCol(0, col))
+	}
+	for row := 0; row < 7; row++ {
+		tr.AddWater(m.FromRowCol(row, 0))
+		if row != 3 {
+			tr.AddWater(m.FromRowCol(row, 6))
+		}
+	}
+	tr.AddWater(m.FromRowCol(1, 2))
+	tr.AddWater(m.FromRowCol(2, 3))
+	tr.AddHill(m.FromRowCol(5, 2))
+	for row := 0; row < 7; row++ {
+		for col := 0; col < 7; col++ {
+			tr.See(m.FromRowCol(row, col))
+		}
+	}
+	tr.Update()
+
+	gap, pocket, right := m.FromRowCol(3, 6), m.FromRowCol(1, 3), m.FromRowCol(4, 9)
+	if !tr.Chokepoint[gap] || !tr.Chokepoint[m.FromRowCol(3, 5)] {
+		t.Errorf("the way through the gap isn't a chokepoint")
+	}
+	if !tr.DeadEnd[pocket] || !tr.DeadEnd[m.FromRowCol(1, 1)] || tr.DeadEnd[m.FromRowCol(1, 4)] || tr.DeadEnd[gap] {
+		t.Errorf("dead ends wrong")
+	}
+	if tr.DeadEnd[right] || tr.DeadEnd[m.FromRowCol(4, 2)] {
+		t.Errorf("dead end with a hill or unexplored land in it")
+	}
+	chokes := map[Location]bool{
+		gap:                true,
+		m.FromRowCol(3, 5): true,
+		m.FromRowCol(3, 7): true,
+		m.FromRowCol(1, 4): true, //the way into the pocket
+		m.FromRowCol(2, 1): true, //the way into the corner
+	}
+	for _, loc := range tr.Chokepoints {
+		if !chokes[loc] {
+			row, col := m.FromLocation(loc)
+			t.Errorf("(%d, %d) is a chokepoint", row, col)
+		}
+	}
+
+	//once the other room's been explored it's a dead end too, however wide
+	for row := 0; row < 7; row++ {
+		for col := 7; col < 12; col++ {
+			tr.See(m.FromRowCol(row, col))
+		}
+	}
+	tr.Chokepoint[gap] = false
+	tr.Update()
+	if !tr.DeadEnd[right] || !tr.DeadEnd[gap] || tr.DeadEnd[m.FromRowCol(3, 5)] || tr.DeadEnd[m.FromRowCol(4, 2)] {
+		t.Errorf("explored room isn't a dead end")
+	}
+
+	//nothing but the dead ends changes without new water
+	if tr.Chokepoint[gap] || tr.Rebuilds != 1 {
+		t.Errorf("rebuilt %d times without new water", tr.Rebuilds)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+const (
+	NO_OWNER  = -1 //nobody can reach the square
+	CONTESTED = -2 //two players reach the square on the same turn
+)
+
+//Territory partitions the map between the players: every square belongs to
+//whoever has an ant or hill closest to it. It is rebuilt each turn with a
+//multi-source BFS that treats everything but known water as passable.
+type Territory struct {
+	m *Map
+
+	Owner    []int //player owning each square, or NO_OWNER/CONTESTED
+	Distance []int //steps from the owner's nearest ant or hill
+	Frontier []Location
+
+	frontier []bool
+	queue    []Location
+}
+
+//NewTerritory returns a territory map for m where nobody owns anything.
+func NewTerritory(m *Map) *Territory {
+	t := &Territory{
+		m:        m,
+		Owner:    make([]int, m.Rows*m.Cols),
+		Distance: make([]int, m.Rows*m.Cols),
+		frontier: make([]bool, m.Rows*m.Cols),
+	}
+	for i := range t.Owner {
+		t.Owner[i] = NO_OWNER
+		t.Distance[i] = -1
+	}
+	return t
+}
+
+//Update recomputes ownership from sources, which maps each ant or hill
+//location to the player it belongs to.
+func (t *Territory) Update(sources map[Location]int) {
+	m := t.m
+	for i := range t.Owner {
+		t.Owner[i] = NO_OWNER
+		t.Distance[i] = -1
+		t.frontier[i] = false
+	}
+	t.Frontier = t.Frontier[:0]
+
+	t.queue = t.queue[:0]
+	for loc, player := range sources {
+		if m.Water.Has(loc) {
+			continue
+		}
+		t.Owner[loc] = player
+		t.Distance[loc] = 0
+		t.queue = append(t.queue, loc)
+	}
+
+	//the queue only grows, so walking it by index is a BFS
+	for i := 0; i < len(t.queue); i++ {
+		loc := t.queue[i]
+		owner := t.Owner[loc]
+		if owner == CONTESTED {
+			continue //nobody gets to claim anything through a contested square
+		}
+		for dir := Direction(0); dir < 4; dir++ {
+			next := m.Move(loc, dir)
+			if m.Water.Has(next) {
+				continue
+			}
+			switch {
+			case t.Distance[next] == -1:
+				t.Distance[next] = t.Distance[loc] + 1
+				t.Owner[next] = owner
+				t.queue = append(t.queue, next)
+			case t.Distance[next] == t.Distance[loc]+1 && t.Owner[next] != owner:
+				t.Owner[next] = CONTESTED
+			}
+		}
+	}
+
+	for _, loc := range t.queue {
+		if t.Owner[loc] < 0 {
+			continue
+		}
+		for dir := Direction(0); dir < 4; dir++ {
+			next := m.Move(loc, dir)
+			if t.Owner[next] != t.Owner[loc] && !m.Water.Has(next) {
+				t.frontier[loc] = true
+				t.Frontier = append(t.Frontier, loc)
+				break
+			}
+		}
+	}
+}
+
+//IsFrontier returns true if loc is owned by a player and borders a square
+//that is owned by somebody else or contested.
+func (t *Territory) IsFrontier(loc Location) bool {
+	return t.frontier[loc]
+}
+
+//FrontierOf returns the frontier squares owned by player.
+func (t *Territory) FrontierOf(player int) []Location {
+	locs := []Location{}
+	for _, loc := range t.Frontier {
+		if t.Owner[loc] == player {
+			locs = append(locs, loc)
+		}
+	}
+	return locs
+}
+
+//Area returns the number of squares owned by player.
+func (t *Territory) Area(player int) int {
+	area := 0
+	for _, owner := range t.Owner {
+		if owner == player {
+			area++
+		}
+	}
+	return area
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTerritory(t *testing.T) {
+	m := NewMap(5, 10)
+	tr := NewTerritory(m)
+	ours, theirs := m.FromRowCol(2, 2), m.FromRowCol(2, 6)
+	m.AddWater(m.FromRowCol(0, 1))
+	tr.Update(map[Location]int{ours: 0, theirs: 1})
+
+	//each side owns what it gets to first, water belongs to nobody
+	if tr.Owner[ours] != 0 || tr.Distance[ours] != 0 {
+		t.Errorf("our ant's square: owner %d, distance %d", tr.Owner[ours], tr.Distance[ours])
+	}
+	if loc := m.FromRowCol(2, 1); tr.Owner[loc] != 0 || tr.Distance[loc] != 1 {
+		t.Errorf("next to our ant: owner %d, distance %d", tr.Owner[loc], tr.Distance[loc])
+	}
+	if loc := m.FromRowCol(4, 7); tr.Owner[loc] != 1 || tr.Distance[loc] != 3 {
+		t.Errorf("near their ant: owner %d, distance %d", tr.Owner[loc], tr.Distance[loc])
+	}
+	if loc := m.FromRowCol(0, 1); tr.Owner[loc] != NO_OWNER {
+		t.Errorf("water owned by %d", tr.Owner[loc])
+	}
+
+	//the columns halfway between the ants, both ways round, are contested
+	for row := 0; row < 5; row++ {
+		for _, col := range []int{4, 9} {
+			if loc := m.FromRowCol(row, col); tr.Owner[loc] != CONTESTED {
+				t.Errorf("%d, %d: owner %d at equal distance", row, col, tr.Owner[loc])
+			}
+		}
+	}
+	if tr.Area(0) != 19 || tr.Area(1) != 20 {
+		t.Errorf("areas %d and %d, expected 19 and 20", tr.Area(0), tr.Area(1))
+	}
+
+	//the frontier is the owned squares up against the contested ones
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 10; col++ {
+			loc := m.FromRowCol(row, col)
+			expected := col == 0 || col == 3 || col == 5 || col == 8
+			if tr.IsFrontier(loc) != expected {
+				t.Errorf("%d, %d: frontier %v", row, col, tr.IsFrontier(loc))
+			}
+		}
+	}
+	if len(tr.FrontierOf(0)) != 10 || len(tr.FrontierOf(1)) != 10 {
+		t.Errorf("%d squares on our frontier, %d on theirs", len(tr.FrontierOf(0)), len(tr.FrontierOf(1)))
+	}
+}
+
+func TestExploreBorder(t *testing.T) {
+	me := testBot(5, 10)
+	m := me.state.Map
+	me.regions.Update()
+	me.territory = NewTerritory(m)
+	ant := me.addAnt(2, 2)
+	me.territory.Update(map[Location]int{ant.loc: 0, m.FromRowCol(2, 6): 1})
+
+	//with no frontier square assigned an explorer heads for the nearest border
+	b := &ExploreBehavior{
+		targets: make(map[Location]Location),
+		border:  me.territory.FrontierOf(0),
+		claimed: make(map[Location]bool),
+	}
+	b.findNewTarget(me, ant)
+	if first := ant.moveTarget; first != m.FromRowCol(2, 3) {
+		t.Errorf("explorer sent to %d rather than the border next to it", first)
+	}
+
+	//and the next one goes somewhere else along it
+	b.findNewTarget(me, ant)
+	if !me.territory.IsFrontier(ant.moveTarget) || ant.moveTarget == m.FromRowCol(2, 3) ||
+		m.Distance2(ant.loc, ant.moveTarget) != 2 {
+		t.Errorf("second explorer sent to %d", ant.moveTarget)
+	}
+}
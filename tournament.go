@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+//TrueSkill defaults, ratings start at MU +/- SIGMA.
+const (
+	TRUESKILL_MU    = 25.0
+	TRUESKILL_SIGMA = TRUESKILL_MU / 3
+	TRUESKILL_BETA  = TRUESKILL_SIGMA / 2
+	TRUESKILL_TAU   = TRUESKILL_SIGMA / 100
+)
+
+//Rating is a TrueSkill-style skill estimate, a normal distribution over the
+//bot's skill.
+type Rating struct {
+	Mu    float64
+	Sigma float64
+}
+
+func NewRating() Rating {
+	return Rating{TRUESKILL_MU, TRUESKILL_SIGMA}
+}
+
+//Conservative is the skill we're 99% sure the bot has at least.
+func (r Rating) Conservative() float64 {
+	return r.Mu - 3*r.Sigma
+}
+
+func gaussPdf(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+func gaussCdf(x float64) float64 {
+	return math.Erfc(-x/math.Sqrt2) / 2
+}
+
+//UpdateRatings adjusts ratings after a game finishing with the given ranks
+//(lower is better). Multiplayer games are treated as every pair of players
+//having played each other, with all the updates computed from the ratings
+//before the game. Ties are ignored.
+func UpdateRatings(ratings []Rating, ranks []int) []Rating {
+	muDelta := make([]float64, len(ratings))
+	varScale := make([]float64, len(ratings))
+	for i := range varScale {
+		varScale[i] = 1
+	}
+	for i := range ratings {
+		for j := range ratings {
+			if ranks[i] >= ranks[j] {
+				continue //only look at each pair once, from the winner's side
+			}
+			win, lose := ratings[i], ratings[j]
+			winVar := win.Sigma*win.Sigma + TRUESKILL_TAU*TRUESKILL_TAU
+			loseVar := lose.Sigma*lose.Sigma + TRUESKILL_TAU*TRUESKILL_TAU
+			c := math.Sqrt(2*TRUESKILL_BETA*TRUESKILL_BETA + winVar + loseVar)
+			t := (win.Mu - lose.Mu) / c
+			v := gaussPdf(t) / math.Fmax(gaussCdf(t), 1e-10)
+			w := v * (v + t)
+
+			muDelta[i] += winVar / c * v
+			muDelta[j] -= loseVar / c * v
+			varScale[i] *= 1 - winVar/(c*c)*w
+			varScale[j] *= 1 - loseVar/(c*c)*w
+		}
+	}
+
+	updated := make([]Rating, len(ratings))
+	for i, r := range ratings {
+		variance := (r.Sigma*r.Sigma + TRUESKILL_TAU*TRUESKILL_TAU) * varScale[i]
+		updated[i] = Rating{r.Mu + muDelta[i], math.Sqrt(variance)}
+	}
+	return updated
+}
+
+//TournamentEntry is the running record of one bot in a tournament.
+type TournamentEntry struct {
+	Name    string
+	Command []string
+
+	Games   int
+	Wins    int
+	RankSum int
+	Rating  Rating
+}
+
+//Tournament plays games between bots on a rotation of maps and seats.
+type Tournament struct {
+	Maps     []string
+	Entries  []*TournamentEntry
+	Seed     int64
+	Turns    int
+	TurnTime int
+
+	Log io.Writer //progress is written here if it isn't nil
+}
+
+//NewTournament sets up a tournament between bots, each either the name of a
+//registered bot (run as this same binary with -bot) or a command line. The
+//registered bots are run with the config file config, unless it's "".
+func NewTournament(maps []string, bots []string, config string, seed int64) *Tournament {
+	t := &Tournament{Maps: maps, Seed: seed, Turns: 1000, TurnTime: 500}
+	for _, bot := range bots {
+		command := strings.Fields(bot)
+		if _, registered := botFactories[bot]; registered {
+			command = []string{os.Args[0], "-bot", bot}
+			if config != "" {
+				command = append(command, "-config", config)
+			}
+		}
+		t.Entries = append(t.Entries, &TournamentEntry{Name: bot, Command: command, Rating: NewRating()})
+	}
+	return t
+}
+
+//Play runs the given number of games. Game g is played on map g mod the
+//number of maps with seed Seed+g, and the bots take turns in the seats.
+func (t *Tournament) Play(games int) os.Error {
+	if len(t.Maps) == 0 || len(t.Entries) == 0 {
+		return os.NewError("tournament needs at least one map and one bot")
+	}
+	for g := 0; g < games; g++ {
+		gm, err := LoadGameMap(t.Maps[g%len(t.Maps)])
+		if err != nil {
+			return err
+		}
+
+		seats := []*TournamentEntry{}
+		names := []string{}
+		commands := [][]string{}
+		for i := 0; i < gm.Players; i++ {
+			entry := t.Entries[(g+i)%len(t.Entries)]
+			seats = append(seats, entry)
+			names = append(names, entry.Name)
+			commands = append(commands, entry.Command)
+		}
+
+		game := NewGame(gm, t.Seed+int64(g))
+		game.Turns = t.Turns
+		game.TurnTime = t.TurnTime
+		ranks, err := game.Play(names, commands)
+		if err != nil {
+			return err
+		}
+		t.record(seats, ranks)
+
+		if t.Log != nil {
+			fmt.Fprintf(t.Log, "game %d on %s:", g, t.Maps[g%len(t.Maps)])
+			for i, entry := range seats {
+				fmt.Fprintf(t.Log, " %s=%d", entry.Name, game.Score[i])
+			}
+			fmt.Fprintf(t.Log, "\n")
+		}
+	}
+	return nil
+}
+
+//record updates the standings with the result of one game.
+func (t *Tournament) record(seats []*TournamentEntry, ranks []int) {
+	ratings := make([]Rating, len(seats))
+	for i, entry := range seats {
+		ratings[i] = entry.Rating
+	}
+	ratings = UpdateRatings(ratings, ranks)
+
+	//a bot may have more than one seat, its rating moves by the sum
+	for i, entry := range seats {
+		entry.Games++
+		entry.RankSum += ranks[i] + 1
+		if ranks[i] == 0 {
+			entry.Wins++
+		}
+	}
+	deltas := make(map[*TournamentEntry]Rating)
+	for i, entry := range seats {
+		d := deltas[entry]
+		d.Mu += ratings[i].Mu - entry.Rating.Mu
+		d.Sigma += ratings[i].Sigma - entry.Rating.Sigma
+		deltas[entry] = d
+	}
+	for entry, d := range deltas {
+		entry.Rating.Mu += d.Mu
+		entry.Rating.Sigma = math.Fmax(entry.Rating.Sigma+d.Sigma, TRUESKILL_TAU)
+	}
+}
+
+//Report writes the standings, with a 95% confidence interval on each rating.
+func (t *Tournament) Report(w io.Writer) {
+	fmt.Fprintf(w, "%-20s %6s %8s %8s %8s %8s  %s\n", "bot", "games", "win%", "avgrank", "mu", "sigma", "95% interval")
+	for _, e := range t.Entries {
+		winRate, avgRank := 0.0, 0.0
+		if e.Games > 0 {
+			winRate = 100 * float64(e.Wins) / float64(e.Games)
+			avgRank = float64(e.RankSum) / float64(e.Games)
+		}
+		fmt.Fprintf(w, "%-20s %6d %8.1f %8.2f %8.2f %8.2f  [%.2f, %.2f]\n", e.Name, e.Games, winRate, avgRank,
+			e.Rating.Mu, e.Rating.Sigma, e.Rating.Mu-1.96*e.Rating.Sigma, e.Rating.Mu+1.96*e.Rating.Sigma)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestUpdateRatings(t *testing.T) {
+	cases := []struct {
+		name    string
+		ratings []Rating
+		ranks   []int
+		moves   []int //which way each mu should go
+	}{
+		{"new players", []Rating{NewRating(), NewRating()}, []int{0, 1}, []int{1, -1}},
+		{"upset", []Rating{{20, 4}, {30, 4}}, []int{0, 1}, []int{1, -1}},
+		{"favourite wins", []Rating{{30, 4}, {20, 4}}, []int{1, 0}, []int{-1, 1}},
+		{"tie", []Rating{NewRating(), NewRating()}, []int{0, 0}, []int{0, 0}},
+		{"three way", []Rating{NewRating(), NewRating(), NewRating()}, []int{2, 0, 1}, []int{-1, 1, 0}},
+	}
+	for _, c := range cases {
+		updated := UpdateRatings(c.ratings, c.ranks)
+		for i, r := range updated {
+			delta := r.Mu - c.ratings[i].Mu
+			switch {
+			case c.moves[i] > 0 && delta <= 0, c.moves[i] < 0 && delta >= 0:
+				t.Errorf("%s: player %d mu moved by %f", c.name, i, delta)
+			case c.moves[i] == 0 && (delta > 1e-9 || delta < -1e-9):
+				t.Errorf("%s: player %d mu moved by %f, want no change", c.name, i, delta)
+			}
+			if c.moves[i] != 0 && r.Sigma >= c.ratings[i].Sigma {
+				t.Errorf("%s: player %d sigma went from %f to %f", c.name, i, c.ratings[i].Sigma, r.Sigma)
+			}
+		}
+	}
+
+	//the underdog gains more for the same win
+	upset := UpdateRatings([]Rating{{20, 4}, {30, 4}}, []int{0, 1})
+	expected := UpdateRatings([]Rating{{30, 4}, {20, 4}}, []int{0, 1})
+	if upset[0].Mu-20 <= expected[0].Mu-30 {
+		t.Errorf("upset gained %f, expected win %f", upset[0].Mu-20, expected[0].Mu-30)
+	}
+}
+
+//testGame returns a game on an open 10x10 map with hills at (0, 0) for
+//player 0 and (9, 9) for player 1, and no ants.
+func testGame() *Game {
+	gm := &GameMap{
+		Rows:    10,
+		Cols:    10,
+		Players: 2,
+		water:   make([]bool, 100),
+		ants:    make(map[Location]int),
+		hills:   make(map[Location]int),
+	}
+	gm.hills[0] = 0
+	gm.hills[99] = 1
+	g := NewGame(gm, 1)
+	g.ants = make(map[Location]*gameAnt)
+	g.dead = make(map[Location]int)
+	return g
+}
+
+func TestEngineResolution(t *testing.T) {
+	type ant struct{ row, col, owner int }
+	battles := []struct {
+		name string
+		ants []ant
+		dead []int //indices into ants
+	}{
+		{"one on one", []ant{{5, 5, 0}, {5, 6, 1}}, []int{0, 1}},
+		{"two on one", []ant{{5, 4, 0}, {6, 4, 0}, {5, 6, 1}}, []int{2}},
+		{"out of range", []ant{{5, 5, 0}, {5, 8, 1}}, []int{}},
+		{"two on two", []ant{{5, 4, 0}, {6, 4, 0}, {5, 6, 1}, {6, 6, 1}}, []int{0, 1, 2, 3}},
+	}
+	for _, b := range battles {
+		g := testGame()
+		locs := []Location{}
+		for _, a := range b.ants {
+			loc := g.m.FromRowCol(a.row, a.col)
+			g.ants[loc] = &gameAnt{owner: a.owner, dest: loc}
+			locs = append(locs, loc)
+		}
+		g.doAttack()
+		for i, loc := range locs {
+			_, died := g.dead[loc]
+			want := false
+			for _, d := range b.dead {
+				want = want || d == i
+			}
+			if died != want {
+				t.Errorf("%s: ant %d died %v, want %v", b.name, i, died, want)
+			}
+			if _, alive := g.ants[loc]; alive == died {
+				t.Errorf("%s: ant %d still on the board %v after dying %v", b.name, i, alive, died)
+			}
+		}
+	}
+
+	//an enemy ant on a hill razes it
+	g := testGame()
+	g.ants[0] = &gameAnt{owner: 1, dest: 0}
+	g.doRaze()
+	if g.hills[0].alive || g.Score[1] != 3 || g.Score[0] != 0 {
+		t.Errorf("razing: hill alive %v, scores %v", g.hills[0].alive, g.Score)
+	}
+
+	//spawning needs food in the hive and a free, living hill
+	g = testGame()
+	g.hive[0], g.hive[1] = 1, 1
+	g.ants[99] = &gameAnt{owner: 1, dest: 99}
+	g.doSpawn()
+	if ant := g.ants[0]; ant == nil || ant.owner != 0 || g.hive[0] != 0 {
+		t.Errorf("no ant spawned on a free hill")
+	}
+	if g.hive[1] != 1 {
+		t.Errorf("spawned onto an occupied hill")
+	}
+}
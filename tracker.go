@@ -0,0 +1,93 @@
+package main
+
+//AntTracker keeps track of which of our ants is which from one turn to the
+//next. The server only tells us where our ants are, so the tracker matches
+//what it sees against where the orders should have taken each ant, and the
+//ants keep their ids and state (paths, targets...) when a move doesn't
+//happen the way we expected.
+type AntTracker struct {
+	m      *Map
+	Ants   map[Location]*Ant //by the square each ant is on, or is heading to once committed
+	nextId int
+}
+
+//Reconciliation is what changed in our ants since the last turn.
+type Reconciliation struct {
+	Born  []*Ant //ants we see for the first time
+	Died  []*Ant //ants we had but can't find, loc is where we last expected them
+	Stuck []*Ant //ants whose move didn't happen, target is where we expected them
+}
+
+//NewAntTracker returns a tracker with no ants.
+func NewAntTracker(m *Map) *AntTracker {
+	return &AntTracker{m: m, Ants: make(map[Location]*Ant)}
+}
+
+//Reconcile matches the ants we know about with the MY_ANT squares on the
+//map. An ant is first looked for where its order should have taken it, then
+//where it was before the order; ants found in neither place are dead, and
+//ants on squares nobody claims are new.
+func (t *AntTracker) Reconcile() *Reconciliation {
+	r := &Reconciliation{}
+	observed := []Location{}
+	for _, loc := range t.m.Ants.Locations() {
+		if t.m.Ants.At(loc) == MY_ANT {
+			observed = append(observed, loc)
+		}
+	}
+
+	found := make(map[Location]*Ant)
+	lost := []*Ant{}
+	for loc, ant := range t.Ants {
+		ant.seenThisTurn = t.m.Ants.At(loc) == MY_ANT
+		if ant.seenThisTurn {
+			found[loc] = ant
+		} else {
+			lost = append(lost, ant)
+		}
+	}
+
+	for _, ant := range lost {
+		if ant.prev != ant.loc && t.m.Ants.At(ant.prev) == MY_ANT && found[ant.prev] == nil {
+			//the order was never carried out, the path it came from is stale
+			ant.target = ant.loc
+			ant.loc = ant.prev
+			ant.moves = nil
+			ant.seenThisTurn = true
+			found[ant.loc] = ant
+			r.Stuck = append(r.Stuck, ant)
+		} else {
+			r.Died = append(r.Died, ant)
+		}
+	}
+
+	for _, loc := range observed {
+		if found[loc] == nil {
+			ant := &Ant{id: t.nextId, loc: loc, state: STATE_EXPLORE, seenThisTurn: true}
+			t.nextId++
+			found[loc] = ant
+			r.Born = append(r.Born, ant)
+		}
+	}
+
+	for loc, ant := range found {
+		ant.prev = loc
+	}
+	t.Ants = found
+	return r
+}
+
+//Commit moves the ants given orders this turn to their targets, which is
+//where Reconcile looks for them first. All the ants leave their squares
+//before any arrive, so an ant can follow another into the square it left.
+func (t *AntTracker) Commit(moved []*Ant) {
+	for _, ant := range moved {
+		if t.Ants[ant.loc] == ant {
+			t.Ants[ant.loc] = nil, false
+		}
+	}
+	for _, ant := range moved {
+		ant.loc = ant.target
+		t.Ants[ant.loc] = ant
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAntTracker(t *testing.T) {
+	m := NewMap(6, 6)
+	tr := NewAntTracker(m)
+	a, b, c := m.FromRowCol(1, 1), m.FromRowCol(1, 2), m.FromRowCol(4, 4)
+	for _, loc := range []Location{a, b, c} {
+		m.AddAnt(loc, MY_ANT)
+	}
+	r := tr.Reconcile()
+	if len(r.Born) != 3 || len(r.Died) != 0 || len(r.Stuck) != 0 {
+		t.Fatalf("first turn: %d born, %d died, %d stuck", len(r.Born), len(r.Died), len(r.Stuck))
+	}
+	antA, antB, antC := tr.Ants[a], tr.Ants[b], tr.Ants[c]
+	antA.state = STATE_HUNT_FOOD
+
+	//a follows b east, c tries to go north but doesn't get there
+	antA.target = b
+	antB.target = m.FromRowCol(1, 3)
+	antC.target = m.FromRowCol(3, 4)
+	tr.Commit([]*Ant{antA, antB, antC})
+	if tr.Ants[b] != antA || tr.Ants[m.FromRowCol(1, 3)] != antB {
+		t.Fatalf("chain move lost track of the ants")
+	}
+
+	//a and c are where we left them next turn, b has died and a new ant hatched
+	m.Reset()
+	m.AddAnt(b, MY_ANT)
+	m.AddAnt(c, MY_ANT)
+	m.AddAnt(m.FromRowCol(0, 5), MY_ANT)
+	r = tr.Reconcile()
+	if tr.Ants[b] != antA || antA.state != STATE_HUNT_FOOD {
+		t.Errorf("the ant that moved lost its identity")
+	}
+	if tr.Ants[c] != antC || len(r.Stuck) != 1 || r.Stuck[0] != antC || antC.loc != c {
+		t.Errorf("the ant whose move failed wasn't found where it started")
+	}
+	if len(r.Died) != 1 || r.Died[0] != antB {
+		t.Errorf("the ant that disappeared wasn't reported dead")
+	}
+	if len(r.Born) != 1 || r.Born[0].id == antA.id || r.Born[0].id == antC.id {
+		t.Errorf("the new ant wasn't reported with a fresh id")
+	}
+	if len(tr.Ants) != 3 {
+		t.Errorf("tracking %d ants, want 3", len(tr.Ants))
+	}
+}